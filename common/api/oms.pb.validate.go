@@ -0,0 +1,3115 @@
+// Code generated by protoc-gen-validate. DO NOT EDIT.
+// source: api/oms.proto
+
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ensure the imports are used
+var (
+	_ = bytes.MinRead
+	_ = errors.New("")
+	_ = fmt.Print
+	_ = utf8.UTFMax
+	_ = (*regexp.Regexp)(nil)
+	_ = (*strings.Reader)(nil)
+	_ = net.IPv4len
+	_ = time.Duration(0)
+	_ = (*url.URL)(nil)
+	_ = (*mail.Address)(nil)
+	_ = anypb.Any{}
+	_ = sort.Sort
+)
+
+// Validate checks the field values on Order with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Order) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Order with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in OrderMultiError, or nil if none found.
+func (m *Order) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Order) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ID
+
+	// no validation rules for CustomerID
+
+	// no validation rules for Status
+
+	for idx, item := range m.GetItems() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, OrderValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, OrderValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return OrderValidationError{
+					field:  fmt.Sprintf("Items[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for PaymentLink
+
+	// no validation rules for Currency
+
+	// no validation rules for Subtotal
+
+	// no validation rules for Tax
+
+	// no validation rules for Total
+
+	// no validation rules for DiscountCode
+
+	// no validation rules for Discount
+
+	if all {
+		switch v := interface{}(m.GetShippingAddress()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, OrderValidationError{
+					field:  "ShippingAddress",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, OrderValidationError{
+					field:  "ShippingAddress",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetShippingAddress()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return OrderValidationError{
+				field:  "ShippingAddress",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetBillingAddress()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, OrderValidationError{
+					field:  "BillingAddress",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, OrderValidationError{
+					field:  "BillingAddress",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetBillingAddress()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return OrderValidationError{
+				field:  "BillingAddress",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for ShippingMethod
+
+	// no validation rules for Shipping
+
+	if len(errors) > 0 {
+		return OrderMultiError(errors)
+	}
+
+	return nil
+}
+
+// OrderMultiError is an error wrapping multiple validation errors returned by
+// Order.ValidateAll() if the designated constraints aren't met.
+type OrderMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m OrderMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m OrderMultiError) AllErrors() []error { return m }
+
+// OrderValidationError is the validation error returned by Order.Validate if
+// the designated constraints aren't met.
+type OrderValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e OrderValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e OrderValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e OrderValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e OrderValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e OrderValidationError) ErrorName() string { return "OrderValidationError" }
+
+// Error satisfies the builtin error interface
+func (e OrderValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sOrder.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = OrderValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = OrderValidationError{}
+
+// Validate checks the field values on Address with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Address) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Address with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in AddressMultiError, or nil if none found.
+func (m *Address) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Address) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Name
+
+	// no validation rules for Line1
+
+	// no validation rules for Line2
+
+	// no validation rules for City
+
+	// no validation rules for State
+
+	// no validation rules for PostalCode
+
+	// no validation rules for Country
+
+	// no validation rules for Phone
+
+	if len(errors) > 0 {
+		return AddressMultiError(errors)
+	}
+
+	return nil
+}
+
+// AddressMultiError is an error wrapping multiple validation errors returned
+// by Address.ValidateAll() if the designated constraints aren't met.
+type AddressMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m AddressMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m AddressMultiError) AllErrors() []error { return m }
+
+// AddressValidationError is the validation error returned by Address.Validate
+// if the designated constraints aren't met.
+type AddressValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e AddressValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e AddressValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e AddressValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e AddressValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e AddressValidationError) ErrorName() string { return "AddressValidationError" }
+
+// Error satisfies the builtin error interface
+func (e AddressValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sAddress.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = AddressValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = AddressValidationError{}
+
+// Validate checks the field values on Product with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Product) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Product with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in ProductMultiError, or nil if none found.
+func (m *Product) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Product) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ID
+
+	// no validation rules for Name
+
+	// no validation rules for TaxCode
+
+	// no validation rules for Description
+
+	// no validation rules for Active
+
+	// no validation rules for Price
+
+	// no validation rules for Quantity
+
+	// no validation rules for Currency
+
+	// no validation rules for Metadata
+
+	if len(errors) > 0 {
+		return ProductMultiError(errors)
+	}
+
+	return nil
+}
+
+// ProductMultiError is an error wrapping multiple validation errors returned
+// by Product.ValidateAll() if the designated constraints aren't met.
+type ProductMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ProductMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ProductMultiError) AllErrors() []error { return m }
+
+// ProductValidationError is the validation error returned by Product.Validate
+// if the designated constraints aren't met.
+type ProductValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ProductValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ProductValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ProductValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ProductValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ProductValidationError) ErrorName() string { return "ProductValidationError" }
+
+// Error satisfies the builtin error interface
+func (e ProductValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sProduct.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ProductValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ProductValidationError{}
+
+// Validate checks the field values on CheckIfItemsInStockRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CheckIfItemsInStockRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CheckIfItemsInStockRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CheckIfItemsInStockRequestMultiError, or nil if none found.
+func (m *CheckIfItemsInStockRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CheckIfItemsInStockRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(m.GetItems()) < 1 {
+		err := CheckIfItemsInStockRequestValidationError{
+			field:  "Items",
+			reason: "value must contain at least 1 item(s)",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	for idx, item := range m.GetItems() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, CheckIfItemsInStockRequestValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, CheckIfItemsInStockRequestValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return CheckIfItemsInStockRequestValidationError{
+					field:  fmt.Sprintf("Items[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return CheckIfItemsInStockRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CheckIfItemsInStockRequestMultiError is an error wrapping multiple
+// validation errors returned by CheckIfItemsInStockRequest.ValidateAll() if
+// the designated constraints aren't met.
+type CheckIfItemsInStockRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CheckIfItemsInStockRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CheckIfItemsInStockRequestMultiError) AllErrors() []error { return m }
+
+// CheckIfItemsInStockRequestValidationError is the validation error returned
+// by CheckIfItemsInStockRequest.Validate if the designated constraints aren't met.
+type CheckIfItemsInStockRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CheckIfItemsInStockRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CheckIfItemsInStockRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CheckIfItemsInStockRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CheckIfItemsInStockRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CheckIfItemsInStockRequestValidationError) ErrorName() string {
+	return "CheckIfItemsInStockRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CheckIfItemsInStockRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCheckIfItemsInStockRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CheckIfItemsInStockRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CheckIfItemsInStockRequestValidationError{}
+
+// Validate checks the field values on CheckIfItemsInStockResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CheckIfItemsInStockResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CheckIfItemsInStockResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CheckIfItemsInStockResponseMultiError, or nil if none found.
+func (m *CheckIfItemsInStockResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CheckIfItemsInStockResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for InStock
+
+	for idx, item := range m.GetItems() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, CheckIfItemsInStockResponseValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, CheckIfItemsInStockResponseValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return CheckIfItemsInStockResponseValidationError{
+					field:  fmt.Sprintf("Items[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return CheckIfItemsInStockResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// CheckIfItemsInStockResponseMultiError is an error wrapping multiple
+// validation errors returned by CheckIfItemsInStockResponse.ValidateAll() if
+// the designated constraints aren't met.
+type CheckIfItemsInStockResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CheckIfItemsInStockResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CheckIfItemsInStockResponseMultiError) AllErrors() []error { return m }
+
+// CheckIfItemsInStockResponseValidationError is the validation error returned
+// by CheckIfItemsInStockResponse.Validate if the designated constraints
+// aren't met.
+type CheckIfItemsInStockResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CheckIfItemsInStockResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CheckIfItemsInStockResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CheckIfItemsInStockResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CheckIfItemsInStockResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CheckIfItemsInStockResponseValidationError) ErrorName() string {
+	return "CheckIfItemsInStockResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CheckIfItemsInStockResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCheckIfItemsInStockResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CheckIfItemsInStockResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CheckIfItemsInStockResponseValidationError{}
+
+// Validate checks the field values on GetItemsRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *GetItemsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetItemsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetItemsRequestMultiError, or nil if none found.
+func (m *GetItemsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetItemsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(errors) > 0 {
+		return GetItemsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetItemsRequestMultiError is an error wrapping multiple validation errors
+// returned by GetItemsRequest.ValidateAll() if the designated constraints
+// aren't met.
+type GetItemsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetItemsRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetItemsRequestMultiError) AllErrors() []error { return m }
+
+// GetItemsRequestValidationError is the validation error returned by
+// GetItemsRequest.Validate if the designated constraints aren't met.
+type GetItemsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetItemsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetItemsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetItemsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetItemsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetItemsRequestValidationError) ErrorName() string { return "GetItemsRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e GetItemsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetItemsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetItemsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetItemsRequestValidationError{}
+
+// Validate checks the field values on GetItemsResponse with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *GetItemsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetItemsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetItemsResponseMultiError, or nil if none found.
+func (m *GetItemsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetItemsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetItems() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, GetItemsResponseValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, GetItemsResponseValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return GetItemsResponseValidationError{
+					field:  fmt.Sprintf("Items[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return GetItemsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetItemsResponseMultiError is an error wrapping multiple validation errors
+// returned by GetItemsResponse.ValidateAll() if the designated constraints
+// aren't met.
+type GetItemsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetItemsResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetItemsResponseMultiError) AllErrors() []error { return m }
+
+// GetItemsResponseValidationError is the validation error returned by
+// GetItemsResponse.Validate if the designated constraints aren't met.
+type GetItemsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetItemsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetItemsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetItemsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetItemsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetItemsResponseValidationError) ErrorName() string { return "GetItemsResponseValidationError" }
+
+// Error satisfies the builtin error interface
+func (e GetItemsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetItemsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetItemsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetItemsResponseValidationError{}
+
+// Validate checks the field values on GetOrderRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *GetOrderRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetOrderRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetOrderRequestMultiError, or nil if none found.
+func (m *GetOrderRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetOrderRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetOrderID()) < 1 {
+		err := GetOrderRequestValidationError{
+			field:  "OrderID",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetCustomerID()) < 1 {
+		err := GetOrderRequestValidationError{
+			field:  "CustomerID",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return GetOrderRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetOrderRequestMultiError is an error wrapping multiple validation errors
+// returned by GetOrderRequest.ValidateAll() if the designated constraints
+// aren't met.
+type GetOrderRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetOrderRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetOrderRequestMultiError) AllErrors() []error { return m }
+
+// GetOrderRequestValidationError is the validation error returned by
+// GetOrderRequest.Validate if the designated constraints aren't met.
+type GetOrderRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetOrderRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetOrderRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetOrderRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetOrderRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetOrderRequestValidationError) ErrorName() string { return "GetOrderRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e GetOrderRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetOrderRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetOrderRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetOrderRequestValidationError{}
+
+// Validate checks the field values on Item with the rules defined in the proto
+// definition for this message. If any rules are violated, the first error
+// encountered is returned, or nil if there are no violations.
+func (m *Item) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Item with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in ItemMultiError, or nil if none found.
+func (m *Item) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Item) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ID
+
+	// no validation rules for Name
+
+	// no validation rules for Quantity
+
+	// no validation rules for PriceID
+
+	// no validation rules for UnitPrice
+
+	// no validation rules for Currency
+
+	// no validation rules for LineTotal
+
+	// no validation rules for TaxCode
+
+	if len(errors) > 0 {
+		return ItemMultiError(errors)
+	}
+
+	return nil
+}
+
+// ItemMultiError is an error wrapping multiple validation errors returned by
+// Item.ValidateAll() if the designated constraints aren't met.
+type ItemMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ItemMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ItemMultiError) AllErrors() []error { return m }
+
+// ItemValidationError is the validation error returned by Item.Validate if the
+// designated constraints aren't met.
+type ItemValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ItemValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ItemValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ItemValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ItemValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ItemValidationError) ErrorName() string { return "ItemValidationError" }
+
+// Error satisfies the builtin error interface
+func (e ItemValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sItem.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ItemValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ItemValidationError{}
+
+// Validate checks the field values on ItemsWithQuantity with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *ItemsWithQuantity) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ItemsWithQuantity with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ItemsWithQuantityMultiError, or nil if none found.
+func (m *ItemsWithQuantity) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ItemsWithQuantity) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetID()) < 1 {
+		err := ItemsWithQuantityValidationError{
+			field:  "ID",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetQuantity() <= 0 {
+		err := ItemsWithQuantityValidationError{
+			field:  "Quantity",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return ItemsWithQuantityMultiError(errors)
+	}
+
+	return nil
+}
+
+// ItemsWithQuantityMultiError is an error wrapping multiple validation errors
+// returned by ItemsWithQuantity.ValidateAll() if the designated constraints
+// aren't met.
+type ItemsWithQuantityMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ItemsWithQuantityMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ItemsWithQuantityMultiError) AllErrors() []error { return m }
+
+// ItemsWithQuantityValidationError is the validation error returned by
+// ItemsWithQuantity.Validate if the designated constraints aren't met.
+type ItemsWithQuantityValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ItemsWithQuantityValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ItemsWithQuantityValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ItemsWithQuantityValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ItemsWithQuantityValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ItemsWithQuantityValidationError) ErrorName() string {
+	return "ItemsWithQuantityValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ItemsWithQuantityValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sItemsWithQuantity.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ItemsWithQuantityValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ItemsWithQuantityValidationError{}
+
+// Validate checks the field values on CreateOrderRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateOrderRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateOrderRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateOrderRequestMultiError, or nil if none found.
+func (m *CreateOrderRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateOrderRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetCustomerID()) < 1 {
+		err := CreateOrderRequestValidationError{
+			field:  "CustomerID",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(m.GetItems()) < 1 {
+		err := CreateOrderRequestValidationError{
+			field:  "Items",
+			reason: "value must contain at least 1 item(s)",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	for idx, item := range m.GetItems() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, CreateOrderRequestValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, CreateOrderRequestValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return CreateOrderRequestValidationError{
+					field:  fmt.Sprintf("Items[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for DiscountCode
+
+	if all {
+		switch v := interface{}(m.GetShippingAddress()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, CreateOrderRequestValidationError{
+					field:  "ShippingAddress",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, CreateOrderRequestValidationError{
+					field:  "ShippingAddress",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetShippingAddress()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return CreateOrderRequestValidationError{
+				field:  "ShippingAddress",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetBillingAddress()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, CreateOrderRequestValidationError{
+					field:  "BillingAddress",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, CreateOrderRequestValidationError{
+					field:  "BillingAddress",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetBillingAddress()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return CreateOrderRequestValidationError{
+				field:  "BillingAddress",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for ShippingMethod
+
+	if len(errors) > 0 {
+		return CreateOrderRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateOrderRequestMultiError is an error wrapping multiple validation errors
+// returned by CreateOrderRequest.ValidateAll() if the designated constraints
+// aren't met.
+type CreateOrderRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateOrderRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateOrderRequestMultiError) AllErrors() []error { return m }
+
+// CreateOrderRequestValidationError is the validation error returned by
+// CreateOrderRequest.Validate if the designated constraints aren't met.
+type CreateOrderRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateOrderRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateOrderRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateOrderRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateOrderRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateOrderRequestValidationError) ErrorName() string {
+	return "CreateOrderRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateOrderRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateOrderRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateOrderRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateOrderRequestValidationError{}
+
+// Validate checks the field values on Promotion with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Promotion) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Promotion with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in PromotionMultiError, or nil
+// if none found.
+func (m *Promotion) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Promotion) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ID
+
+	// no validation rules for Code
+
+	// no validation rules for Type
+
+	// no validation rules for PercentOff
+
+	// no validation rules for AmountOff
+
+	// no validation rules for Currency
+
+	// no validation rules for MinimumAmount
+
+	// no validation rules for BuyItemID
+
+	// no validation rules for BuyQuantity
+
+	// no validation rules for GetQuantity
+
+	if all {
+		switch v := interface{}(m.GetStartsAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, PromotionValidationError{
+					field:  "StartsAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, PromotionValidationError{
+					field:  "StartsAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetStartsAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return PromotionValidationError{
+				field:  "StartsAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetEndsAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, PromotionValidationError{
+					field:  "EndsAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, PromotionValidationError{
+					field:  "EndsAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetEndsAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return PromotionValidationError{
+				field:  "EndsAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for MaxRedemptions
+
+	// no validation rules for Redemptions
+
+	// no validation rules for Active
+
+	if len(errors) > 0 {
+		return PromotionMultiError(errors)
+	}
+
+	return nil
+}
+
+// PromotionMultiError is an error wrapping multiple validation errors returned
+// by Promotion.ValidateAll() if the designated constraints aren't met.
+type PromotionMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m PromotionMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m PromotionMultiError) AllErrors() []error { return m }
+
+// PromotionValidationError is the validation error returned by
+// Promotion.Validate if the designated constraints aren't met.
+type PromotionValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e PromotionValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e PromotionValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e PromotionValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e PromotionValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e PromotionValidationError) ErrorName() string { return "PromotionValidationError" }
+
+// Error satisfies the builtin error interface
+func (e PromotionValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sPromotion.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = PromotionValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = PromotionValidationError{}
+
+// Validate checks the field values on GetPromotionRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetPromotionRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetPromotionRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetPromotionRequestMultiError, or nil if none found.
+func (m *GetPromotionRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetPromotionRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetID()) < 1 {
+		err := GetPromotionRequestValidationError{
+			field:  "ID",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return GetPromotionRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetPromotionRequestMultiError is an error wrapping multiple validation
+// errors returned by GetPromotionRequest.ValidateAll() if the designated
+// constraints aren't met.
+type GetPromotionRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetPromotionRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetPromotionRequestMultiError) AllErrors() []error { return m }
+
+// GetPromotionRequestValidationError is the validation error returned by
+// GetPromotionRequest.Validate if the designated constraints aren't met.
+type GetPromotionRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetPromotionRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetPromotionRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetPromotionRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetPromotionRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetPromotionRequestValidationError) ErrorName() string {
+	return "GetPromotionRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetPromotionRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetPromotionRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetPromotionRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetPromotionRequestValidationError{}
+
+// Validate checks the field values on ListPromotionsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListPromotionsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListPromotionsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListPromotionsResponseMultiError, or nil if none found.
+func (m *ListPromotionsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListPromotionsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetPromotions() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListPromotionsResponseValidationError{
+						field:  fmt.Sprintf("Promotions[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListPromotionsResponseValidationError{
+						field:  fmt.Sprintf("Promotions[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListPromotionsResponseValidationError{
+					field:  fmt.Sprintf("Promotions[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return ListPromotionsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListPromotionsResponseMultiError is an error wrapping multiple validation
+// errors returned by ListPromotionsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type ListPromotionsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListPromotionsResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListPromotionsResponseMultiError) AllErrors() []error { return m }
+
+// ListPromotionsResponseValidationError is the validation error returned by
+// ListPromotionsResponse.Validate if the designated constraints aren't met.
+type ListPromotionsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListPromotionsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListPromotionsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListPromotionsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListPromotionsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListPromotionsResponseValidationError) ErrorName() string {
+	return "ListPromotionsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListPromotionsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListPromotionsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListPromotionsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListPromotionsResponseValidationError{}
+
+// Validate checks the field values on CreateItemRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *CreateItemRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateItemRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateItemRequestMultiError, or nil if none found.
+func (m *CreateItemRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateItemRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Name
+
+	// no validation rules for Description
+
+	// no validation rules for Price
+
+	// no validation rules for Currency
+
+	// no validation rules for Quantity
+
+	// no validation rules for Metadata
+
+	// no validation rules for TaxCode
+
+	if len(errors) > 0 {
+		return CreateItemRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateItemRequestMultiError is an error wrapping multiple validation errors
+// returned by CreateItemRequest.ValidateAll() if the designated constraints
+// aren't met.
+type CreateItemRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateItemRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateItemRequestMultiError) AllErrors() []error { return m }
+
+// CreateItemRequestValidationError is the validation error returned by
+// CreateItemRequest.Validate if the designated constraints aren't met.
+type CreateItemRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateItemRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateItemRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateItemRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateItemRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateItemRequestValidationError) ErrorName() string {
+	return "CreateItemRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateItemRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateItemRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateItemRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateItemRequestValidationError{}
+
+// Validate checks the field values on CreateItemResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateItemResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateItemResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateItemResponseMultiError, or nil if none found.
+func (m *CreateItemResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateItemResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ObjectID
+
+	if len(errors) > 0 {
+		return CreateItemResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateItemResponseMultiError is an error wrapping multiple validation errors
+// returned by CreateItemResponse.ValidateAll() if the designated constraints
+// aren't met.
+type CreateItemResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateItemResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateItemResponseMultiError) AllErrors() []error { return m }
+
+// CreateItemResponseValidationError is the validation error returned by
+// CreateItemResponse.Validate if the designated constraints aren't met.
+type CreateItemResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateItemResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateItemResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateItemResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateItemResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateItemResponseValidationError) ErrorName() string {
+	return "CreateItemResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateItemResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateItemResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateItemResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateItemResponseValidationError{}
+
+// Validate checks the field values on StockItem with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *StockItem) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on StockItem with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in StockItemMultiError, or nil
+// if none found.
+func (m *StockItem) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *StockItem) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Id
+
+	// no validation rules for ProductId
+
+	// no validation rules for Name
+
+	// no validation rules for Description
+
+	// no validation rules for Price
+
+	// no validation rules for Currency
+
+	// no validation rules for Quantity
+
+	// no validation rules for Active
+
+	// no validation rules for PriceId
+
+	// no validation rules for Metadata
+
+	if all {
+		switch v := interface{}(m.GetCreatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, StockItemValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, StockItemValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetCreatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return StockItemValidationError{
+				field:  "CreatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetUpdatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, StockItemValidationError{
+					field:  "UpdatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, StockItemValidationError{
+					field:  "UpdatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetUpdatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return StockItemValidationError{
+				field:  "UpdatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for TaxCode
+
+	if len(errors) > 0 {
+		return StockItemMultiError(errors)
+	}
+
+	return nil
+}
+
+// StockItemMultiError is an error wrapping multiple validation errors returned
+// by StockItem.ValidateAll() if the designated constraints aren't met.
+type StockItemMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m StockItemMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m StockItemMultiError) AllErrors() []error { return m }
+
+// StockItemValidationError is the validation error returned by
+// StockItem.Validate if the designated constraints aren't met.
+type StockItemValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e StockItemValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e StockItemValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e StockItemValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e StockItemValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e StockItemValidationError) ErrorName() string { return "StockItemValidationError" }
+
+// Error satisfies the builtin error interface
+func (e StockItemValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sStockItem.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = StockItemValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = StockItemValidationError{}
+
+// Validate checks the field values on GetStockItemsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetStockItemsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetStockItemsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetStockItemsResponseMultiError, or nil if none found.
+func (m *GetStockItemsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetStockItemsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetItems() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, GetStockItemsResponseValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, GetStockItemsResponseValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return GetStockItemsResponseValidationError{
+					field:  fmt.Sprintf("Items[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return GetStockItemsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetStockItemsResponseMultiError is an error wrapping multiple validation
+// errors returned by GetStockItemsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type GetStockItemsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetStockItemsResponseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetStockItemsResponseMultiError) AllErrors() []error { return m }
+
+// GetStockItemsResponseValidationError is the validation error returned by
+// GetStockItemsResponse.Validate if the designated constraints aren't met.
+type GetStockItemsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetStockItemsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetStockItemsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetStockItemsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetStockItemsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetStockItemsResponseValidationError) ErrorName() string {
+	return "GetStockItemsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetStockItemsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetStockItemsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetStockItemsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetStockItemsResponseValidationError{}
+
+// Validate checks the field values on GetStockItemRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetStockItemRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetStockItemRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetStockItemRequestMultiError, or nil if none found.
+func (m *GetStockItemRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetStockItemRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetId()) < 1 {
+		err := GetStockItemRequestValidationError{
+			field:  "Id",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return GetStockItemRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetStockItemRequestMultiError is an error wrapping multiple validation
+// errors returned by GetStockItemRequest.ValidateAll() if the designated
+// constraints aren't met.
+type GetStockItemRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetStockItemRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetStockItemRequestMultiError) AllErrors() []error { return m }
+
+// GetStockItemRequestValidationError is the validation error returned by
+// GetStockItemRequest.Validate if the designated constraints aren't met.
+type GetStockItemRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetStockItemRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetStockItemRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetStockItemRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetStockItemRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetStockItemRequestValidationError) ErrorName() string {
+	return "GetStockItemRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetStockItemRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetStockItemRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetStockItemRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetStockItemRequestValidationError{}
+
+// Validate checks the field values on UpdateStockItemRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *UpdateStockItemRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UpdateStockItemRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UpdateStockItemRequestMultiError, or nil if none found.
+func (m *UpdateStockItemRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UpdateStockItemRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Id
+
+	// no validation rules for Name
+
+	// no validation rules for Description
+
+	// no validation rules for Price
+
+	// no validation rules for Currency
+
+	// no validation rules for Quantity
+
+	// no validation rules for Metadata
+
+	// no validation rules for Active
+
+	// no validation rules for TaxCode
+
+	if len(errors) > 0 {
+		return UpdateStockItemRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// UpdateStockItemRequestMultiError is an error wrapping multiple validation
+// errors returned by UpdateStockItemRequest.ValidateAll() if the designated
+// constraints aren't met.
+type UpdateStockItemRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UpdateStockItemRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UpdateStockItemRequestMultiError) AllErrors() []error { return m }
+
+// UpdateStockItemRequestValidationError is the validation error returned by
+// UpdateStockItemRequest.Validate if the designated constraints aren't met.
+type UpdateStockItemRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UpdateStockItemRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UpdateStockItemRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UpdateStockItemRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UpdateStockItemRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UpdateStockItemRequestValidationError) ErrorName() string {
+	return "UpdateStockItemRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e UpdateStockItemRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUpdateStockItemRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UpdateStockItemRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UpdateStockItemRequestValidationError{}
+
+// Validate checks the field values on UpdateStockQuantityRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *UpdateStockQuantityRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UpdateStockQuantityRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UpdateStockQuantityRequestMultiError, or nil if none found.
+func (m *UpdateStockQuantityRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UpdateStockQuantityRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetID()) < 1 {
+		err := UpdateStockQuantityRequestValidationError{
+			field:  "ID",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if m.GetQuantity() < 0 {
+		err := UpdateStockQuantityRequestValidationError{
+			field:  "Quantity",
+			reason: "value must be greater than or equal to 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return UpdateStockQuantityRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// UpdateStockQuantityRequestMultiError is an error wrapping multiple
+// validation errors returned by UpdateStockQuantityRequest.ValidateAll() if
+// the designated constraints aren't met.
+type UpdateStockQuantityRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UpdateStockQuantityRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UpdateStockQuantityRequestMultiError) AllErrors() []error { return m }
+
+// UpdateStockQuantityRequestValidationError is the validation error returned
+// by UpdateStockQuantityRequest.Validate if the designated constraints aren't met.
+type UpdateStockQuantityRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UpdateStockQuantityRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UpdateStockQuantityRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UpdateStockQuantityRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UpdateStockQuantityRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UpdateStockQuantityRequestValidationError) ErrorName() string {
+	return "UpdateStockQuantityRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e UpdateStockQuantityRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUpdateStockQuantityRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UpdateStockQuantityRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UpdateStockQuantityRequestValidationError{}
+
+// Validate checks the field values on DeleteItemRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *DeleteItemRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeleteItemRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeleteItemRequestMultiError, or nil if none found.
+func (m *DeleteItemRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteItemRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetID()) < 1 {
+		err := DeleteItemRequestValidationError{
+			field:  "ID",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return DeleteItemRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeleteItemRequestMultiError is an error wrapping multiple validation errors
+// returned by DeleteItemRequest.ValidateAll() if the designated constraints
+// aren't met.
+type DeleteItemRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeleteItemRequestMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeleteItemRequestMultiError) AllErrors() []error { return m }
+
+// DeleteItemRequestValidationError is the validation error returned by
+// DeleteItemRequest.Validate if the designated constraints aren't met.
+type DeleteItemRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeleteItemRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeleteItemRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeleteItemRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeleteItemRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeleteItemRequestValidationError) ErrorName() string {
+	return "DeleteItemRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DeleteItemRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeleteItemRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeleteItemRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeleteItemRequestValidationError{}
+
+// Validate checks the field values on Empty with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Empty) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Empty with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in EmptyMultiError, or nil if none found.
+func (m *Empty) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Empty) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(errors) > 0 {
+		return EmptyMultiError(errors)
+	}
+
+	return nil
+}
+
+// EmptyMultiError is an error wrapping multiple validation errors returned by
+// Empty.ValidateAll() if the designated constraints aren't met.
+type EmptyMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m EmptyMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m EmptyMultiError) AllErrors() []error { return m }
+
+// EmptyValidationError is the validation error returned by Empty.Validate if
+// the designated constraints aren't met.
+type EmptyValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e EmptyValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e EmptyValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e EmptyValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e EmptyValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e EmptyValidationError) ErrorName() string { return "EmptyValidationError" }
+
+// Error satisfies the builtin error interface
+func (e EmptyValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sEmpty.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = EmptyValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = EmptyValidationError{}
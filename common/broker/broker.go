@@ -0,0 +1,92 @@
+package broker
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	ExchangeDirect = "direct"
+	ExchangeFanout = "fanout"
+)
+
+var (
+	ErrExchangeNotFound = errors.New("exchange not found")
+	ErrQueueNotFound    = errors.New("queue not found")
+	ErrClosed           = errors.New("broker is closed")
+	ErrNacked           = errors.New("message was nacked by the broker")
+	ErrUnroutable       = errors.New("message could not be routed")
+	ErrConfirmTimeout   = errors.New("timed out waiting for publish confirmation")
+)
+
+type Message struct {
+	MessageID   string
+	ContentType string
+	Headers     map[string]interface{}
+	Body        []byte
+}
+
+type Acknowledger interface {
+	Ack() error
+	Nack(requeue bool) error
+}
+
+type Delivery struct {
+	Message
+	Exchange     string
+	RoutingKey   string
+	Redelivered  bool
+	Acknowledger Acknowledger
+}
+
+func (d Delivery) Ack() error {
+	return d.Acknowledger.Ack()
+}
+
+func (d Delivery) Nack(requeue bool) error {
+	return d.Acknowledger.Nack(requeue)
+}
+
+type QueueOptions struct {
+	Durable    bool
+	AutoDelete bool
+	Exclusive  bool
+	Args       map[string]interface{}
+}
+
+type SubscribeOptions struct {
+	// Prefetch caps the unacknowledged deliveries held by the subscriber, zero
+	// meaning no limit.
+	Prefetch int
+}
+
+// Publish returns once the broker has taken responsibility for msg, failing
+// with ErrUnroutable if no queue accepted it.
+type Publisher interface {
+	Publish(ctx context.Context, exchange, routingKey string, msg Message) error
+}
+
+// Subscribe delivers messages from queue until ctx is cancelled, after which
+// the returned channel is closed. Deliveries already received can still be
+// settled until release is called, which requeues any left unsettled.
+type Subscriber interface {
+	Subscribe(ctx context.Context, queue string, opts SubscribeOptions) (deliveries <-chan Delivery, release func() error, err error)
+	// Get fetches a single unacknowledged message from queue, if one is ready.
+	Get(ctx context.Context, queue string) (Delivery, bool, error)
+}
+
+type Declarer interface {
+	DeclareExchange(name, kind string) error
+	// DeclareQueue returns the queue name, generated by the broker when name is empty.
+	DeclareQueue(name string, opts QueueOptions) (string, error)
+	BindQueue(queue, routingKey, exchange string) error
+}
+
+type Broker interface {
+	Publisher
+	Subscriber
+	Declarer
+	// Healthy reports whether the broker is currently connected.
+	Healthy() bool
+	Close() error
+}
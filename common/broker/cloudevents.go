@@ -0,0 +1,133 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// CloudEvents 1.0 over AMQP. In binary mode the attributes travel as ce-*
+// headers and the body is the payload; in structured mode the whole event is
+// the JSON body. Trace context stays in the headers either way.
+const (
+	CloudEventsContentType = "application/cloudevents+json"
+
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsPrefix      = "ce-"
+	payloadContentType     = "application/json"
+)
+
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	CorrelationID   string          `json:"correlationid,omitempty"`
+	EventVersion    int             `json:"eventversion"`
+	Data            json.RawMessage `json:"data"`
+}
+
+func encodeStructured(ctx context.Context, e Event) (Message, error) {
+	body, err := json.Marshal(cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              e.ID,
+		Source:          e.Source,
+		Type:            e.Type,
+		Time:            e.OccurredAt,
+		DataContentType: payloadContentType,
+		CorrelationID:   e.CorrelationID,
+		EventVersion:    e.Version,
+		Data:            e.Payload,
+	})
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		MessageID:   e.ID,
+		ContentType: CloudEventsContentType,
+		Headers:     InjectAMQPHeaders(ctx),
+		Body:        body,
+	}, nil
+}
+
+func encodeBinary(ctx context.Context, e Event) (Message, error) {
+	headers := InjectAMQPHeaders(ctx)
+	headers[cloudEventsPrefix+"specversion"] = cloudEventsSpecVersion
+	headers[cloudEventsPrefix+"id"] = e.ID
+	headers[cloudEventsPrefix+"source"] = e.Source
+	headers[cloudEventsPrefix+"type"] = e.Type
+	headers[cloudEventsPrefix+"time"] = e.OccurredAt.Format(time.RFC3339Nano)
+	headers[cloudEventsPrefix+"eventversion"] = strconv.Itoa(e.Version)
+	if e.CorrelationID != "" {
+		headers[cloudEventsPrefix+"correlationid"] = e.CorrelationID
+	}
+
+	return Message{
+		MessageID:   e.ID,
+		ContentType: payloadContentType,
+		Headers:     headers,
+		Body:        e.Payload,
+	}, nil
+}
+
+func isBinaryCloudEvent(msg Message) bool {
+	_, ok := msg.Headers[cloudEventsPrefix+"specversion"]
+	return ok
+}
+
+func decodeStructured(msg Message) (Event, error) {
+	var ce cloudEvent
+	if err := json.Unmarshal(msg.Body, &ce); err != nil {
+		return Event{}, err
+	}
+
+	if ce.SpecVersion != cloudEventsSpecVersion {
+		return Event{}, fmt.Errorf("unsupported cloudevents specversion %q", ce.SpecVersion)
+	}
+
+	return Event{
+		ID:            ce.ID,
+		Type:          ce.Type,
+		Version:       ce.EventVersion,
+		OccurredAt:    ce.Time,
+		Source:        ce.Source,
+		CorrelationID: ce.CorrelationID,
+		Payload:       ce.Data,
+	}, nil
+}
+
+func decodeBinary(msg Message) (Event, error) {
+	attr := func(name string) string {
+		v, _ := msg.Headers[cloudEventsPrefix+name].(string)
+		return v
+	}
+
+	if v := attr("specversion"); v != cloudEventsSpecVersion {
+		return Event{}, fmt.Errorf("unsupported cloudevents specversion %q", v)
+	}
+
+	version, err := strconv.Atoi(attr("eventversion"))
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid cloudevents eventversion: %w", err)
+	}
+
+	occurredAt, err := time.Parse(time.RFC3339Nano, attr("time"))
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid cloudevents time: %w", err)
+	}
+
+	return Event{
+		ID:            attr("id"),
+		Type:          attr("type"),
+		Version:       version,
+		OccurredAt:    occurredAt,
+		Source:        attr("source"),
+		CorrelationID: attr("correlationid"),
+		Payload:       msg.Body,
+	}, nil
+}
@@ -0,0 +1,524 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/juxue97/common"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var defaultReconnect = common.RetryConfig{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+type Status struct {
+	Connected  bool
+	Reconnects int
+	LastError  error
+	Since      time.Time
+}
+
+type declarationKind int
+
+const (
+	declareExchange declarationKind = iota
+	declareQueue
+	bindQueue
+)
+
+// declaration records topology so it can be re-applied after a reconnect.
+type declaration struct {
+	kind         declarationKind
+	name         string
+	exchangeKind string
+	opts         QueueOptions
+	generated    bool
+	routingKey   string
+	exchange     string
+}
+
+// connection owns the AMQP connection, redialling whenever the broker closes
+// it, and the long-lived channels opened on it. A channel exception closes
+// only the channel that caused it, which is reopened on the same connection.
+type connection struct {
+	address string
+	backoff common.RetryConfig
+
+	mu       sync.RWMutex
+	conn     *amqp.Connection
+	ready    chan struct{}
+	lost     chan struct{} // closed once conn is given up
+	status   Status
+	topology []declaration
+	// server-named queues get a new name on every reconnect
+	names map[string]string
+
+	// consumers and declarations open channels of their own
+	publisher *managedChannel
+	getter    *managedChannel
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newConnection(address string, backoff common.RetryConfig) *connection {
+	return &connection{
+		address:   address,
+		backoff:   backoff,
+		ready:     make(chan struct{}),
+		names:     map[string]string{},
+		publisher: newManagedChannel(true),
+		getter:    newManagedChannel(false),
+		done:      make(chan struct{}),
+	}
+}
+
+func (c *connection) dial() error {
+	conn, err := amqp.Dial(c.address)
+	if err != nil {
+		return err
+	}
+
+	if err := c.redeclare(conn); err != nil {
+		conn.Close()
+		return err
+	}
+
+	for _, m := range []*managedChannel{c.publisher, c.getter} {
+		if err := c.openChannel(conn, m); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	c.mu.Lock()
+	c.conn = conn
+	c.lost = make(chan struct{})
+	c.status.Connected = true
+	c.status.Since = time.Now()
+	close(c.ready)
+	c.mu.Unlock()
+
+	go c.watch(connClosed)
+
+	return nil
+}
+
+func (c *connection) watch(connClosed chan *amqp.Error) {
+	var reason *amqp.Error
+	select {
+	case <-c.done:
+		return
+	case reason = <-connClosed:
+	}
+
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+
+	c.mu.Lock()
+	c.conn = nil
+	c.ready = make(chan struct{})
+	close(c.lost)
+	c.status.Connected = false
+	c.status.Since = time.Now()
+	if reason != nil {
+		c.status.LastError = reason
+	}
+	c.mu.Unlock()
+
+	log.Printf("rabbitmq connection lost: %v, reconnecting", reason)
+
+	c.reconnect()
+}
+
+func (c *connection) reconnect() {
+	for attempt := 1; ; attempt++ {
+		timer := time.NewTimer(c.backoff.Backoff(attempt))
+		select {
+		case <-c.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := c.dial(); err != nil {
+			log.Printf("rabbitmq reconnect attempt %d failed: %v", attempt, err)
+			c.mu.Lock()
+			c.status.LastError = err
+			c.mu.Unlock()
+			continue
+		}
+
+		c.mu.Lock()
+		c.status.Reconnects++
+		c.mu.Unlock()
+
+		log.Printf("rabbitmq reconnected after %d attempts", attempt)
+		return
+	}
+}
+
+// managedChannel is a long-lived channel that is reopened whenever it
+// closes. Publishing uses one in confirm mode, recording returns on it.
+type managedChannel struct {
+	confirm bool
+
+	mu      sync.RWMutex
+	ch      *amqp.Channel
+	returns *returns
+	ready   chan struct{}
+	lost    chan struct{} // closed once ch is given up
+}
+
+func newManagedChannel(confirm bool) *managedChannel {
+	return &managedChannel{confirm: confirm, ready: make(chan struct{})}
+}
+
+func (m *managedChannel) set(ch *amqp.Channel, returns *returns) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ch != nil {
+		// replaced on a new connection before its watcher gave it up
+		close(m.lost)
+	} else {
+		close(m.ready)
+	}
+	m.ch, m.returns = ch, returns
+	m.lost = make(chan struct{})
+}
+
+// drop gives up ch unless it has been replaced already.
+func (m *managedChannel) drop(ch *amqp.Channel) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ch != ch {
+		return
+	}
+	m.ch, m.returns = nil, nil
+	m.ready = make(chan struct{})
+	close(m.lost)
+}
+
+func (c *connection) openChannel(conn *amqp.Connection, m *managedChannel) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+
+	var returns *returns
+	if m.confirm {
+		if err := ch.Confirm(false); err != nil {
+			ch.Close()
+			return err
+		}
+		returns = watchReturns(ch.NotifyReturn(make(chan amqp.Return)))
+	}
+
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	m.set(ch, returns)
+
+	go c.watchChannel(conn, m, ch, closed)
+
+	return nil
+}
+
+// watchChannel reopens m on conn once ch closes. When the connection itself
+// went away, watch redials it and dial reopens m instead.
+func (c *connection) watchChannel(conn *amqp.Connection, m *managedChannel, ch *amqp.Channel, closed chan *amqp.Error) {
+	var reason *amqp.Error
+	select {
+	case <-c.done:
+		return
+	case reason = <-closed:
+	}
+
+	m.drop(ch)
+	if conn.IsClosed() {
+		return
+	}
+
+	log.Printf("rabbitmq channel closed: %v, reopening", reason)
+
+	for attempt := 1; ; attempt++ {
+		err := c.openChannel(conn, m)
+		if err == nil || conn.IsClosed() {
+			return
+		}
+		log.Printf("rabbitmq channel reopen attempt %d failed: %v", attempt, err)
+
+		timer := time.NewTimer(c.backoff.Backoff(attempt))
+		select {
+		case <-c.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// returns records the messages the broker could not route on one channel,
+// keyed by message id, for the publishes tracking them.
+type returns struct {
+	mu       sync.Mutex
+	returned map[string]*amqp.Return
+
+	flush   chan chan struct{}
+	stopped chan struct{}
+}
+
+func watchReturns(notify <-chan amqp.Return) *returns {
+	r := &returns{
+		returned: map[string]*amqp.Return{},
+		flush:    make(chan chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go r.run(notify)
+
+	return r
+}
+
+// run records returns until the channel closes. RabbitMQ sends the return
+// ahead of the confirm and the client hands it over on the unbuffered notify
+// channel before it reads the ack, so once a confirm is seen the return has
+// been received here, though maybe not yet recorded. A flush is only served
+// between two returns, which makes it wait for that.
+func (r *returns) run(notify <-chan amqp.Return) {
+	defer close(r.stopped)
+
+	for {
+		select {
+		case ret, ok := <-notify:
+			if !ok {
+				return
+			}
+			r.mu.Lock()
+			if _, ok := r.returned[ret.MessageId]; ok {
+				r.returned[ret.MessageId] = &ret
+			}
+			r.mu.Unlock()
+		case flushed := <-r.flush:
+			close(flushed)
+		}
+	}
+}
+
+func (r *returns) track(messageID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.returned[messageID] = nil
+}
+
+// untrack returns the basic.return of a publish whose confirm was seen, nil
+// if the message was routed.
+func (r *returns) untrack(messageID string) *amqp.Return {
+	flushed := make(chan struct{})
+	select {
+	case r.flush <- flushed:
+		<-flushed
+	case <-r.stopped:
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ret := r.returned[messageID]
+	delete(r.returned, messageID)
+	return ret
+}
+
+func (c *connection) redeclare(conn *amqp.Connection) error {
+	c.mu.RLock()
+	topology := append([]declaration(nil), c.topology...)
+	c.mu.RUnlock()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	for _, d := range topology {
+		if err := c.apply(ch, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *connection) apply(ch *amqp.Channel, d declaration) error {
+	switch d.kind {
+	case declareExchange:
+		return ch.ExchangeDeclare(d.name, d.exchangeKind, true, false, false, false, nil)
+	case declareQueue:
+		name := d.name
+		if d.generated {
+			name = ""
+		}
+		q, err := ch.QueueDeclare(name, d.opts.Durable, d.opts.AutoDelete, d.opts.Exclusive, false, d.opts.Args)
+		if err != nil {
+			return err
+		}
+		if d.generated {
+			c.mu.Lock()
+			c.names[d.name] = q.Name
+			c.mu.Unlock()
+		}
+		return nil
+	case bindQueue:
+		return ch.QueueBind(c.resolve(d.name), d.routingKey, d.exchange, false, nil)
+	}
+
+	return nil
+}
+
+func (c *connection) record(d declaration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.topology = append(c.topology, d)
+}
+
+// resolve maps the name a server-named queue was first declared with to its
+// name on the current connection.
+func (c *connection) resolve(queue string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if name, ok := c.names[queue]; ok {
+		return name
+	}
+	return queue
+}
+
+// open opens a channel of its own for the caller to close, waiting for a
+// reconnect if needed.
+func (c *connection) open(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		c.mu.RLock()
+		conn, ready, lost := c.conn, c.ready, c.lost
+		c.mu.RUnlock()
+
+		if conn != nil {
+			ch, err := conn.Channel()
+			if !errors.Is(err, amqp.ErrClosed) {
+				return ch, err
+			}
+			// the connection dropped before watch gave it up
+			ready = lost
+		}
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.done:
+			return nil, ErrClosed
+		}
+	}
+}
+
+// withChannel runs fn on a short-lived channel, so an exception it raises,
+// such as a conflicting declaration, closes nothing else.
+func (c *connection) withChannel(ctx context.Context, fn func(*amqp.Channel) error) error {
+	ch, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	return fn(ch)
+}
+
+// current returns m's channel with the returns recorded on it, waiting for
+// it to be reopened if needed.
+func (c *connection) current(ctx context.Context, m *managedChannel) (*amqp.Channel, *returns, error) {
+	for {
+		m.mu.RLock()
+		ch, returns, ready := m.ch, m.returns, m.ready
+		m.mu.RUnlock()
+
+		if ch != nil {
+			return ch, returns, nil
+		}
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-c.done:
+			return nil, nil, ErrClosed
+		}
+	}
+}
+
+// replaced waits until stale is no longer m's channel, so work that failed
+// on it can be retried on the next one.
+func (c *connection) replaced(ctx context.Context, m *managedChannel, stale *amqp.Channel) error {
+	m.mu.RLock()
+	ch, lost := m.ch, m.lost
+	m.mu.RUnlock()
+
+	if ch != stale {
+		return nil
+	}
+
+	select {
+	case <-lost:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return ErrClosed
+	}
+}
+
+func (c *connection) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *connection) close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.status.Connected = false
+		if c.conn != nil {
+			err = c.conn.Close()
+		}
+	})
+
+	if errors.Is(err, amqp.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+func (c *connection) health() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.status
+}
@@ -0,0 +1,145 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler processes a delivery. Returning nil acks it, a Permanent error
+// sends it straight to the DLQ and any other error schedules a retry.
+type Handler func(ctx context.Context, d Delivery) error
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one retrying cannot fix.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// ErrAnonymousQueue is returned for a consumer without a named queue.
+var ErrAnonymousQueue = errors.New("consumer queue must be durable and named")
+
+type ConsumerConfig struct {
+	// Queue must be a durable, named queue such as one declared by
+	// SubscriberTopology. Every replica consumes from the same queue, so
+	// they compete for messages rather than each receiving a copy.
+	Queue    string
+	Workers  int
+	Prefetch int
+	// Timeout bounds the handling of a single message.
+	Timeout time.Duration
+	Retry   RetryPolicy
+}
+
+type Consumer struct {
+	broker  Broker
+	config  ConsumerConfig
+	handler Handler
+	retrier *Retrier
+}
+
+func NewConsumer(b Broker, config ConsumerConfig, handler Handler) (*Consumer, error) {
+	// server-named queues are exclusive to one connection and lost with it
+	if config.Queue == "" || strings.HasPrefix(config.Queue, "amq.gen-") {
+		return nil, fmt.Errorf("%w: %q", ErrAnonymousQueue, config.Queue)
+	}
+
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.Prefetch < config.Workers {
+		config.Prefetch = config.Workers
+	}
+
+	retrier, err := NewRetrier(b, config.Queue, config.Retry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Consumer{broker: b, config: config, handler: handler, retrier: retrier}, nil
+}
+
+// Run consumes until ctx is done, then stops taking deliveries and returns
+// once the messages already being handled are settled.
+func (c *Consumer) Run(ctx context.Context) error {
+	deliveries, release, err := c.broker.Subscribe(ctx, c.config.Queue, SubscribeOptions{Prefetch: c.config.Prefetch})
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range deliveries {
+				c.process(d)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// only now that every worker is done can the subscription let go of
+	// the deliveries they settled
+	if err := release(); err != nil {
+		log.Printf("failed to release subscription to %s: %v", c.config.Queue, err)
+	}
+
+	if ctx.Err() == nil {
+		return fmt.Errorf("consuming %s: %w", c.config.Queue, ErrClosed)
+	}
+
+	return nil
+}
+
+func (c *Consumer) process(d Delivery) {
+	// in-flight messages are not cancelled by shutdown, only by the timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	if c.config.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), c.config.Timeout)
+	}
+	defer cancel()
+
+	err := c.handle(ctx, d)
+
+	var permanent *permanentError
+	switch {
+	case err == nil:
+		if err := d.Ack(); err != nil {
+			log.Printf("failed to ack message from %s: %v", c.config.Queue, err)
+		}
+	case errors.As(err, &permanent):
+		if err := c.retrier.Reject(context.Background(), d, err); err != nil {
+			log.Printf("failed to reject message from %s: %v", c.config.Queue, err)
+		}
+	default:
+		if err := c.retrier.HandleRetry(context.Background(), d, err); err != nil {
+			log.Printf("failed to handle retry: %v", err)
+		}
+	}
+}
+
+func (c *Consumer) handle(ctx context.Context, d Delivery) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic handling message from %s: %v\n%s", c.config.Queue, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return c.handler(ctx, d)
+}
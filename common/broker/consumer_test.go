@@ -0,0 +1,188 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/broker"
+	"github.com/juxue97/common/broker/inmem"
+)
+
+func TestConsumer(t *testing.T) {
+	b := inmem.NewBroker()
+	if err := broker.DeclareTopology(b); err != nil {
+		t.Fatal(err)
+	}
+	b.DeclareQueue("work", broker.QueueOptions{Durable: true})
+
+	var handled atomic.Int32
+	consumer, err := broker.NewConsumer(b, broker.ConsumerConfig{
+		Queue:   "work",
+		Workers: 2,
+		Timeout: time.Second,
+		Retry:   broker.RetryPolicy{MaxAttempts: 1},
+	}, func(ctx context.Context, d broker.Delivery) error {
+		defer handled.Add(1)
+
+		switch string(d.Body) {
+		case "panic":
+			panic("boom")
+		case "bad":
+			return broker.Permanent(errors.New("bad payload"))
+		case "slow":
+			time.Sleep(50 * time.Millisecond)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- consumer.Run(ctx) }()
+
+	for _, body := range []string{"ok", "panic", "bad", "slow"} {
+		b.Publish(ctx, "", "work", broker.Message{Body: []byte(body)})
+	}
+
+	deadline := time.After(time.Second)
+	for handled.Load() < 4 {
+		select {
+		case <-deadline:
+			t.Fatalf("handled %d of 4 messages", handled.Load())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("consumer did not drain")
+	}
+
+	if got := b.Ready("dlq_main"); got != 2 {
+		t.Errorf("expected the panicking and bad messages in the dlq, got %d", got)
+	}
+	if got := b.Ready("work"); got != 0 {
+		t.Errorf("expected work queue to be empty, got %d", got)
+	}
+}
+
+func TestConsumerReplicasCompete(t *testing.T) {
+	b := inmem.NewBroker()
+	retry := broker.RetryPolicy{MaxAttempts: 1}
+	topology := broker.SharedTopology.Merge(
+		broker.SubscriberTopology("stocks", broker.OrderPaidEvent, retry),
+		broker.SubscriberTopology("orders", broker.OrderPaidEvent, retry),
+	)
+	if err := topology.Apply(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := broker.NewConsumer(b, broker.ConsumerConfig{}, nil); !errors.Is(err, broker.ErrAnonymousQueue) {
+		t.Errorf("expected ErrAnonymousQueue without a queue, got %v", err)
+	}
+
+	// published before any consumer runs, as if the services were down
+	const events = 20
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < events; i++ {
+		if err := b.Publish(ctx, broker.OrderPaidEvent, "", broker.Message{Body: []byte("paid")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var handled [2]atomic.Int32
+	done := make(chan error, 2)
+	for i := range handled {
+		replica := &handled[i]
+		consumer, err := broker.NewConsumer(b, broker.ConsumerConfig{
+			Queue: broker.ServiceQueue("stocks", broker.OrderPaidEvent),
+			Retry: retry,
+		}, func(ctx context.Context, d broker.Delivery) error {
+			replica.Add(1)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() { done <- consumer.Run(ctx) }()
+	}
+
+	deadline := time.After(time.Second)
+	for handled[0].Load()+handled[1].Load() < events {
+		select {
+		case <-deadline:
+			t.Fatalf("handled %d of %d events", handled[0].Load()+handled[1].Load(), events)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	<-done
+	<-done
+
+	if got := handled[0].Load() + handled[1].Load(); got != events {
+		t.Errorf("expected each event handled once across replicas, got %d", got)
+	}
+	if got := b.Ready(broker.ServiceQueue("orders", broker.OrderPaidEvent)); got != events {
+		t.Errorf("expected the orders queue to keep its own copy of every event, got %d", got)
+	}
+}
+
+func TestConsumerAcksDuringShutdown(t *testing.T) {
+	b := inmem.NewBroker()
+	if err := broker.DeclareTopology(b); err != nil {
+		t.Fatal(err)
+	}
+	b.DeclareQueue("work", broker.QueueOptions{Durable: true})
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	var handled atomic.Int32
+	consumer, err := broker.NewConsumer(b, broker.ConsumerConfig{
+		Queue: "work",
+		Retry: broker.RetryPolicy{MaxAttempts: 1},
+	}, func(ctx context.Context, d broker.Delivery) error {
+		handled.Add(1)
+		close(started)
+		<-finish
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- consumer.Run(ctx) }()
+
+	b.Publish(ctx, "", "work", broker.Message{Body: []byte("slow")})
+	<-started
+
+	// the message is acked only after shutdown has begun
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	close(finish)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("consumer did not drain")
+	}
+
+	if got := b.Ready("work"); got != 0 || handled.Load() != 1 {
+		t.Errorf("expected the message handled once and not redelivered, got %d handled and %d ready", handled.Load(), got)
+	}
+}
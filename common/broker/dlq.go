@@ -0,0 +1,297 @@
+package broker
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	scanMarkerHeader = "x-dlq-scan"
+	staleScanAfter   = time.Hour
+)
+
+var ErrNoOrigin = errors.New("message has no origin to replay to")
+
+type DeadLetter struct {
+	ID         string `json:"id"`
+	Exchange   string `json:"exchange"`
+	RoutingKey string `json:"routingKey"`
+	Queue      string `json:"queue"`
+	RetryCount int64  `json:"retryCount"`
+	LastError  string `json:"lastError"`
+	FailedAt   string `json:"failedAt"`
+	Body       string `json:"body"`
+}
+
+// DLQ inspects dlq_main. Replay, Purge and listing everything make one pass
+// over the queue, fetching messages one at a time and putting back the ones
+// that were not replayed or purged.
+type DLQ struct {
+	mu     sync.Mutex
+	broker Broker
+	queue  string
+}
+
+func NewDLQ(b Broker) *DLQ {
+	return &DLQ{broker: b, queue: dlq}
+}
+
+// List returns up to limit dead letters, or all of them when limit is zero.
+// A limited list holds the messages it reads and requeues them where they
+// were. Listing everything republishes every dead letter, as scan does, so
+// prefer a limit on a long queue.
+func (q *DLQ) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	if limit > 0 {
+		return q.peek(ctx, limit)
+	}
+
+	var res []DeadLetter
+	err := q.scan(ctx, func(d Delivery) (bool, error) {
+		res = append(res, toDeadLetter(d))
+		return false, nil
+	})
+
+	return res, err
+}
+
+// peek reads up to limit dead letters from the head of the queue, leaving
+// them unacknowledged until it is done.
+func (q *DLQ) peek(ctx context.Context, limit int) (res []DeadLetter, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var held []Delivery
+	defer func() {
+		// requeued last first, in case the broker puts them at the head
+		for i := len(held) - 1; i >= 0; i-- {
+			err = errors.Join(err, held[i].Nack(true))
+		}
+	}()
+
+	for len(res) < limit {
+		d, ok, err := q.broker.Get(ctx, q.queue)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		held = append(held, d)
+		if _, marker := d.Headers[scanMarkerHeader]; !marker {
+			res = append(res, toDeadLetter(d))
+		}
+	}
+
+	return res, nil
+}
+
+// Replay publishes the selected dead letters back to the queue that failed
+// them, or to their original exchange if the queue is unknown. An empty ids
+// replays everything. A dead letter that can't be replayed is left in the
+// queue and reported in the error, without stopping the rest.
+func (q *DLQ) Replay(ctx context.Context, ids []string) (int, error) {
+	selected := selection(ids)
+
+	count := 0
+	err := q.scan(ctx, func(d Delivery) (bool, error) {
+		id := deadLetterID(d)
+		if !selected(id) {
+			return false, nil
+		}
+
+		exchange, routingKey, ok := origin(d)
+		if !ok {
+			return false, fmt.Errorf("%s: %w", id, ErrNoOrigin)
+		}
+
+		headers := make(map[string]interface{}, len(d.Headers))
+		for k, v := range d.Headers {
+			headers[k] = v
+		}
+		delete(headers, retryCountHeader)
+
+		err := q.broker.Publish(ctx, exchange, routingKey, Message{
+			MessageID:   d.MessageID,
+			ContentType: d.ContentType,
+			Headers:     headers,
+			Body:        d.Body,
+		})
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", id, err)
+		}
+
+		count++
+		return true, nil
+	})
+
+	return count, err
+}
+
+// Purge drops the selected dead letters. An empty ids purges everything.
+func (q *DLQ) Purge(ctx context.Context, ids []string) (int, error) {
+	selected := selection(ids)
+
+	count := 0
+	err := q.scan(ctx, func(d Delivery) (bool, error) {
+		if !selected(deadLetterID(d)) {
+			return false, nil
+		}
+
+		count++
+		return true, nil
+	})
+
+	return count, err
+}
+
+// scan passes each message to fn once, which reports whether it consumed
+// the message (it is then acked) and any error to report once the pass is
+// over. Every other message is published back to the tail of the queue
+// before it is acked, so no more than one is held unacknowledged. A marker
+// published first ends the pass once it comes round, leaving the messages in
+// their order; anything dead-lettered meanwhile is put ahead of them.
+//
+// A crash between republishing a message and acking it leaves two copies in
+// the queue. A pass hands only the first copy of each dead letter to fn and
+// puts the others back. Dead letters are told apart by the queue that failed
+// them as well as their id, since a fanout event failed by several consumers
+// is dead-lettered once from each queue under the same message ID.
+func (q *DLQ) scan(ctx context.Context, fn func(d Delivery) (bool, error)) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	marker := uuid.NewString()
+	err := q.broker.Publish(ctx, "", q.queue, Message{
+		MessageID: marker,
+		Headers:   map[string]interface{}{scanMarkerHeader: time.Now().UTC().Format(time.RFC3339)},
+	})
+	if err != nil {
+		return err
+	}
+
+	var fnErr error
+	seen := map[string]bool{}
+	for {
+		d, ok, err := q.broker.Get(ctx, q.queue)
+		if err != nil {
+			return errors.Join(fnErr, err)
+		}
+		if !ok {
+			// the marker was taken by someone else
+			return fnErr
+		}
+
+		if started, ok := d.Headers[scanMarkerHeader].(string); ok {
+			if d.MessageID == marker || staleMarker(started) {
+				if err := d.Ack(); err != nil {
+					return errors.Join(fnErr, err)
+				}
+				if d.MessageID == marker {
+					return fnErr
+				}
+				continue
+			}
+		} else {
+			key := scanKey(d)
+			if !seen[key] {
+				seen[key] = true
+				consumed, err := fn(d)
+				fnErr = errors.Join(fnErr, err)
+				if consumed {
+					if err := d.Ack(); err != nil {
+						return errors.Join(fnErr, err)
+					}
+					continue
+				}
+			}
+		}
+
+		if err := q.broker.Publish(ctx, "", q.queue, d.Message); err != nil {
+			d.Nack(true)
+			return errors.Join(fnErr, err)
+		}
+		if err := d.Ack(); err != nil {
+			return errors.Join(fnErr, err)
+		}
+	}
+}
+
+// staleMarker reports whether a marker was left by a scan that never came
+// round to it, as when the process died midway.
+func staleMarker(started string) bool {
+	t, err := time.Parse(time.RFC3339, started)
+	return err != nil || time.Since(t) > staleScanAfter
+}
+
+func selection(ids []string) func(string) bool {
+	if len(ids) == 0 {
+		return func(string) bool { return true }
+	}
+
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+
+	return func(id string) bool { return set[id] }
+}
+
+func origin(d Delivery) (string, string, bool) {
+	if queue, ok := d.Headers[originQueueHeader].(string); ok && queue != "" {
+		return "", queue, true
+	}
+
+	exchange, ok := d.Headers[originExchangeHeader].(string)
+	if !ok {
+		return "", "", false
+	}
+	routingKey, _ := d.Headers[originRoutingKeyHeader].(string)
+
+	return exchange, routingKey, true
+}
+
+// scanKey identifies a dead letter within a pass by the queue that failed it
+// and its id.
+func scanKey(d Delivery) string {
+	queue, _ := d.Headers[originQueueHeader].(string)
+	return queue + "/" + deadLetterID(d)
+}
+
+// deadLetterID falls back to a hash of the body for messages dead-lettered
+// before message IDs were assigned.
+func deadLetterID(d Delivery) string {
+	if d.MessageID != "" {
+		return d.MessageID
+	}
+
+	sum := sha1.Sum(d.Body)
+	return hex.EncodeToString(sum[:8])
+}
+
+func toDeadLetter(d Delivery) DeadLetter {
+	dl := DeadLetter{ID: deadLetterID(d), Body: string(d.Body)}
+	dl.Exchange, _ = d.Headers[originExchangeHeader].(string)
+	dl.RoutingKey, _ = d.Headers[originRoutingKeyHeader].(string)
+	dl.Queue, _ = d.Headers[originQueueHeader].(string)
+	dl.LastError, _ = d.Headers[lastErrorHeader].(string)
+	dl.FailedAt, _ = d.Headers[failedAtHeader].(string)
+
+	switch count := d.Headers[retryCountHeader].(type) {
+	case int64:
+		dl.RetryCount = count
+	case int32:
+		dl.RetryCount = int64(count)
+	case int:
+		dl.RetryCount = int64(count)
+	}
+
+	return dl
+}
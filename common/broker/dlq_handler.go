@@ -0,0 +1,101 @@
+package broker
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/juxue97/common"
+)
+
+type DLQHandler struct {
+	dlq   *DLQ
+	token string
+}
+
+// NewDLQHandler serves the DLQ admin API, authenticated with a bearer token.
+func NewDLQHandler(dlq *DLQ, token string) *DLQHandler {
+	return &DLQHandler{dlq: dlq, token: token}
+}
+
+type dlqRequest struct {
+	IDs []string `json:"ids"`
+	All bool     `json:"all"`
+}
+
+func (h *DLQHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/dlq", h.authorize(h.handleList))
+	mux.HandleFunc("POST /admin/dlq/replay", h.authorize(h.handleReplay))
+	mux.HandleFunc("POST /admin/dlq/purge", h.authorize(h.handlePurge))
+}
+
+func (h *DLQHandler) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if h.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			common.WriteError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleList lists all dead letters without a limit, which republishes the
+// whole queue.
+func (h *DLQHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			common.WriteError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	messages, err := h.dlq.List(r.Context(), limit)
+	if err != nil {
+		common.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	common.JsonResponse(w, http.StatusOK, messages)
+}
+
+func (h *DLQHandler) handleReplay(w http.ResponseWriter, r *http.Request) {
+	h.handleSelection(w, r, h.dlq.Replay)
+}
+
+func (h *DLQHandler) handlePurge(w http.ResponseWriter, r *http.Request) {
+	h.handleSelection(w, r, h.dlq.Purge)
+}
+
+func (h *DLQHandler) handleSelection(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, ids []string) (int, error)) {
+	var payload dlqRequest
+	if err := common.ReadJSON(w, r, &payload); err != nil {
+		common.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// acting on the whole queue has to be asked for explicitly
+	if len(payload.IDs) == 0 && !payload.All {
+		common.WriteError(w, http.StatusBadRequest, "ids or all is required")
+		return
+	}
+
+	count, err := fn(r.Context(), payload.IDs)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrNoOrigin) {
+			status = http.StatusUnprocessableEntity
+		}
+		// the rest of the selection was still acted on
+		common.WriteJSON(w, status, map[string]any{"error": err.Error(), "count": count})
+		return
+	}
+
+	common.JsonResponse(w, http.StatusOK, map[string]int{"count": count})
+}
@@ -0,0 +1,235 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/broker"
+	"github.com/juxue97/common/broker/inmem"
+)
+
+func TestDLQ(t *testing.T) {
+	ctx := context.Background()
+
+	b := inmem.NewBroker()
+	if err := broker.DeclareTopology(b); err != nil {
+		t.Fatal(err)
+	}
+	b.DeclareQueue("work", broker.QueueOptions{Durable: true})
+
+	for _, id := range []string{"a", "b", "c"} {
+		b.Publish(ctx, "", "dlq_main", broker.Message{
+			MessageID: id,
+			Body:      []byte(id),
+			Headers: map[string]interface{}{
+				"x-retry-count":        int64(3),
+				"x-origin-exchange":    "",
+				"x-origin-routing-key": "work",
+				"x-origin-queue":       "work",
+				"x-last-error":         "boom",
+			},
+		})
+	}
+
+	dlq := broker.NewDLQ(b)
+
+	messages, err := dlq.List(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 dead letters, got %d", len(messages))
+	}
+	if m := messages[0]; m.ID != "a" || m.Queue != "work" || m.RetryCount != 3 || m.LastError != "boom" {
+		t.Errorf("unexpected dead letter %+v", m)
+	}
+	if b.Ready("dlq_main") != 3 {
+		t.Error("listing should leave messages in the dlq")
+	}
+
+	count, err := dlq.Replay(ctx, []string{"b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || b.Ready("work") != 1 || b.Ready("dlq_main") != 2 {
+		t.Errorf("expected b replayed to work, got count %d, work %d, dlq %d", count, b.Ready("work"), b.Ready("dlq_main"))
+	}
+
+	d, _, _ := b.Get(ctx, "work")
+	if _, ok := d.Headers["x-retry-count"]; ok {
+		t.Error("replayed message should start with a fresh retry count")
+	}
+
+	messages, _ = dlq.List(ctx, 0)
+	if len(messages) != 2 || messages[0].ID != "a" || messages[1].ID != "c" {
+		t.Errorf("expected a and c left in order, got %+v", messages)
+	}
+
+	count, err = dlq.Purge(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 || b.Ready("dlq_main") != 0 {
+		t.Errorf("expected dlq purged, got count %d, dlq %d", count, b.Ready("dlq_main"))
+	}
+}
+
+// heldBroker counts the deliveries fetched with Get and not yet settled.
+type heldBroker struct {
+	*inmem.Broker
+	held, maxHeld int
+}
+
+func (b *heldBroker) Get(ctx context.Context, queue string) (broker.Delivery, bool, error) {
+	d, ok, err := b.Broker.Get(ctx, queue)
+	if ok {
+		b.held++
+		b.maxHeld = max(b.maxHeld, b.held)
+		d.Acknowledger = &heldAcknowledger{Acknowledger: d.Acknowledger, broker: b}
+	}
+	return d, ok, err
+}
+
+type heldAcknowledger struct {
+	broker.Acknowledger
+	broker *heldBroker
+}
+
+func (a *heldAcknowledger) Ack() error {
+	a.broker.held--
+	return a.Acknowledger.Ack()
+}
+
+func (a *heldAcknowledger) Nack(requeue bool) error {
+	a.broker.held--
+	return a.Acknowledger.Nack(requeue)
+}
+
+func TestDLQHoldsOneMessageAtATime(t *testing.T) {
+	ctx := context.Background()
+
+	b := &heldBroker{Broker: inmem.NewBroker()}
+	if err := broker.DeclareTopology(b); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprint(i)
+		ids = append(ids, id)
+		b.Publish(ctx, "", "dlq_main", broker.Message{MessageID: id, Body: []byte(id)})
+	}
+	// left behind by a scan that died an hour ago
+	b.Publish(ctx, "", "dlq_main", broker.Message{
+		MessageID: "old-scan",
+		Headers:   map[string]interface{}{"x-dlq-scan": time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)},
+	})
+
+	dlq := broker.NewDLQ(b)
+
+	messages, err := dlq.List(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 10 || messages[9].ID != "9" {
+		t.Fatalf("expected the first 10 dead letters, got %+v", messages)
+	}
+	// a limited list holds what it reads instead of rotating the queue
+	if b.maxHeld != 10 || b.held != 0 {
+		t.Errorf("expected the 10 listed to be held and requeued, got %d held at most, %d still held", b.maxHeld, b.held)
+	}
+	b.maxHeld = 0
+
+	if _, err := dlq.Purge(ctx, []string{"0", "50"}); err != nil {
+		t.Fatal(err)
+	}
+	if b.maxHeld != 1 {
+		t.Errorf("expected one message held at a time, got %d", b.maxHeld)
+	}
+
+	messages, _ = dlq.List(ctx, 0)
+	var got []string
+	for _, m := range messages {
+		got = append(got, m.ID)
+	}
+	want := append(append([]string(nil), ids[1:50]...), ids[51:]...)
+	if !slices.Equal(got, want) {
+		t.Errorf("expected the dead letters in order without markers, got %v", got)
+	}
+}
+
+func TestDLQReplayKeepsGoingAndSkipsDuplicates(t *testing.T) {
+	ctx := context.Background()
+
+	b := inmem.NewBroker()
+	if err := broker.DeclareTopology(b); err != nil {
+		t.Fatal(err)
+	}
+	b.DeclareQueue("work", broker.QueueOptions{Durable: true})
+
+	origin := map[string]interface{}{"x-origin-queue": "work"}
+	// b has nowhere to go, and c was left twice by a scan that crashed
+	for _, m := range []broker.Message{
+		{MessageID: "a", Headers: origin},
+		{MessageID: "b"},
+		{MessageID: "c", Headers: origin},
+		{MessageID: "c", Headers: origin},
+	} {
+		b.Publish(ctx, "", "dlq_main", m)
+	}
+
+	count, err := broker.NewDLQ(b).Replay(ctx, nil)
+	if !errors.Is(err, broker.ErrNoOrigin) || !strings.Contains(err.Error(), "b: ") {
+		t.Errorf("expected b to be reported without an origin, got %v", err)
+	}
+	// the second copy of c is put back rather than dropped
+	if count != 2 || b.Ready("work") != 2 || b.Ready("dlq_main") != 2 {
+		t.Errorf("expected a and c replayed once each, got count %d, work %d, dlq %d", count, b.Ready("work"), b.Ready("dlq_main"))
+	}
+}
+
+func TestDLQKeepsDeadLettersFromEachQueue(t *testing.T) {
+	ctx := context.Background()
+
+	b := inmem.NewBroker()
+	if err := broker.DeclareTopology(b); err != nil {
+		t.Fatal(err)
+	}
+	b.DeclareQueue("orders", broker.QueueOptions{Durable: true})
+	b.DeclareQueue("stock", broker.QueueOptions{Durable: true})
+
+	// one fanout event failed by two consumers
+	for _, queue := range []string{"orders", "stock"} {
+		b.Publish(ctx, "", "dlq_main", broker.Message{
+			MessageID: "paid",
+			Headers:   map[string]interface{}{"x-origin-queue": queue},
+		})
+	}
+
+	dlq := broker.NewDLQ(b)
+
+	messages, err := dlq.List(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || messages[0].Queue != "orders" || messages[1].Queue != "stock" {
+		t.Fatalf("expected a dead letter from each queue, got %+v", messages)
+	}
+	if b.Ready("dlq_main") != 2 {
+		t.Fatalf("listing should leave both dead letters, got %d", b.Ready("dlq_main"))
+	}
+
+	count, err := dlq.Replay(ctx, []string{"paid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 || b.Ready("orders") != 1 || b.Ready("stock") != 1 || b.Ready("dlq_main") != 0 {
+		t.Errorf("expected paid replayed to both queues, got count %d, orders %d, stock %d, dlq %d",
+			count, b.Ready("orders"), b.Ready("stock"), b.Ready("dlq_main"))
+	}
+}
@@ -0,0 +1,242 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const EventContentType = "application/vnd.oms.event+json"
+
+const (
+	eventTypeHeader    = "x-event-type"
+	eventVersionHeader = "x-event-version"
+)
+
+var (
+	ErrUnknownEventType    = errors.New("unknown event type")
+	ErrUnexpectedEventType = errors.New("unexpected event type")
+	ErrUnsupportedVersion  = errors.New("unsupported event version")
+)
+
+type Event struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	Version       int             `json:"version"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Source        string          `json:"source"`
+	CorrelationID string          `json:"correlationId,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Upcaster converts a payload to the next version of its schema.
+type Upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+type Schema struct {
+	Type string
+	// Version is the version published by this build.
+	Version int
+	// Upcasters[v] converts a version v payload to version v+1.
+	Upcasters map[int]Upcaster
+}
+
+type Encoding string
+
+const (
+	EncodingEnvelope              Encoding = "envelope"
+	EncodingCloudEventsBinary     Encoding = "cloudevents-binary"
+	EncodingCloudEventsStructured Encoding = "cloudevents-structured"
+)
+
+type Registry struct {
+	sync.RWMutex
+	schemas  map[string]Schema
+	encoding Encoding
+}
+
+func NewRegistry(schemas ...Schema) *Registry {
+	r := &Registry{schemas: map[string]Schema{}, encoding: EncodingEnvelope}
+	for _, s := range schemas {
+		r.Register(s)
+	}
+	return r
+}
+
+func (r *Registry) Register(s Schema) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.schemas[s.Type] = s
+}
+
+// SetEncoding selects how events are encoded by Encode. Decode accepts every
+// encoding regardless.
+func (r *Registry) SetEncoding(encoding Encoding) error {
+	switch encoding {
+	case EncodingEnvelope, EncodingCloudEventsBinary, EncodingCloudEventsStructured:
+	default:
+		return fmt.Errorf("unknown event encoding %q", encoding)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.encoding = encoding
+
+	return nil
+}
+
+func (r *Registry) schema(eventType string) (Schema, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	s, ok := r.schemas[eventType]
+	if !ok {
+		return Schema{}, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+	return s, nil
+}
+
+// NewEvent wraps payload in an envelope at the current schema version.
+func (r *Registry) NewEvent(ctx context.Context, source, eventType string, payload any) (Event, error) {
+	s, err := r.schema(eventType)
+	if err != nil {
+		return Event{}, err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+
+	id := uuid.NewString()
+	correlationID := CorrelationID(ctx)
+	if correlationID == "" {
+		correlationID = id
+	}
+
+	return Event{
+		ID:            id,
+		Type:          eventType,
+		Version:       s.Version,
+		OccurredAt:    time.Now().UTC(),
+		Source:        source,
+		CorrelationID: correlationID,
+		Payload:       body,
+	}, nil
+}
+
+// Encode encodes e for publishing in the registry's encoding, carrying the
+// trace context of ctx.
+func (r *Registry) Encode(ctx context.Context, e Event) (Message, error) {
+	r.RLock()
+	encoding := r.encoding
+	r.RUnlock()
+
+	switch encoding {
+	case EncodingCloudEventsBinary:
+		return encodeBinary(ctx, e)
+	case EncodingCloudEventsStructured:
+		return encodeStructured(ctx, e)
+	default:
+		return encodeEnvelope(ctx, e)
+	}
+}
+
+func encodeEnvelope(ctx context.Context, e Event) (Message, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return Message{}, err
+	}
+
+	headers := InjectAMQPHeaders(ctx)
+	headers[eventTypeHeader] = e.Type
+	headers[eventVersionHeader] = int64(e.Version)
+
+	return Message{
+		MessageID:   e.ID,
+		ContentType: EventContentType,
+		Headers:     headers,
+		Body:        body,
+	}, nil
+}
+
+// Decode reads the envelope of d in any encoding, checks it carries
+// eventType, upcasts older versions and unmarshals the payload into v.
+// Messages published before envelopes were introduced are read as version 1
+// of eventType.
+func (r *Registry) Decode(d Delivery, eventType string, v any) (Event, error) {
+	var e Event
+	var err error
+	switch {
+	case d.ContentType == EventContentType:
+		err = json.Unmarshal(d.Body, &e)
+	case d.ContentType == CloudEventsContentType:
+		e, err = decodeStructured(d.Message)
+	case isBinaryCloudEvent(d.Message):
+		e, err = decodeBinary(d.Message)
+	default:
+		e = Event{ID: d.MessageID, Type: eventType, Version: 1, Payload: d.Body}
+	}
+	if err != nil {
+		return Event{}, err
+	}
+
+	if e.Type != eventType {
+		return e, fmt.Errorf("%w: got %s, want %s", ErrUnexpectedEventType, e.Type, eventType)
+	}
+
+	s, err := r.schema(e.Type)
+	if err != nil {
+		return e, err
+	}
+
+	if e.Version > s.Version || e.Version < 1 {
+		return e, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, e.Type, e.Version)
+	}
+
+	for e.Version < s.Version {
+		upcast, ok := s.Upcasters[e.Version]
+		if !ok {
+			return e, fmt.Errorf("%w: no upcaster for %s v%d", ErrUnsupportedVersion, e.Type, e.Version)
+		}
+
+		e.Payload, err = upcast(e.Payload)
+		if err != nil {
+			return e, err
+		}
+		e.Version++
+	}
+
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return e, err
+	}
+
+	return e, nil
+}
+
+type correlationIDKey struct{}
+
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID carried by ctx, falling back to
+// the trace ID of the current span.
+func CorrelationID(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationIDKey{}).(string); ok && id != "" {
+		return id
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+
+	return ""
+}
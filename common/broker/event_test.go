@@ -0,0 +1,113 @@
+package broker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	pb "github.com/juxue97/common/api"
+	"github.com/juxue97/common/broker"
+)
+
+func TestEvents(t *testing.T) {
+	ctx := broker.WithCorrelationID(context.Background(), "corr-1")
+
+	t.Run("round trip", func(t *testing.T) {
+		event, err := broker.Events.NewEvent(ctx, "payments", broker.OrderPaidEvent, broker.OrderPaid{OrderID: "42", CustomerID: "7"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msg, err := broker.Events.Encode(ctx, event)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var paid broker.OrderPaid
+		got, err := broker.Events.Decode(broker.Delivery{Message: msg}, broker.OrderPaidEvent, &paid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.ID != event.ID || got.Version != 2 || got.Source != "payments" || got.CorrelationID != "corr-1" {
+			t.Errorf("unexpected envelope %+v", got)
+		}
+		if paid.OrderID != "42" || paid.CustomerID != "7" {
+			t.Errorf("unexpected payload %+v", paid)
+		}
+	})
+
+	t.Run("upcasts legacy order.paid", func(t *testing.T) {
+		body, _ := json.Marshal(&pb.Order{ID: "42", CustomerID: "7", Status: "paid", Shipping: 500})
+
+		var paid broker.OrderPaid
+		_, err := broker.Events.Decode(broker.Delivery{Message: broker.Message{
+			ContentType: "application/json",
+			Body:        body,
+		}}, broker.OrderPaidEvent, &paid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if paid.OrderID != "42" || paid.CustomerID != "7" || paid.Shipping != 500 {
+			t.Errorf("unexpected payload %+v", paid)
+		}
+	})
+
+	t.Run("rejects unknown versions and types", func(t *testing.T) {
+		event, _ := broker.Events.NewEvent(ctx, "payments", broker.OrderPaidEvent, broker.OrderPaid{})
+		event.Version = 3
+		msg, _ := broker.Events.Encode(ctx, event)
+
+		var paid broker.OrderPaid
+		_, err := broker.Events.Decode(broker.Delivery{Message: msg}, broker.OrderPaidEvent, &paid)
+		if !errors.Is(err, broker.ErrUnsupportedVersion) {
+			t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+		}
+
+		_, err = broker.Events.Decode(broker.Delivery{Message: msg}, broker.OrderCreatedEvent, &pb.Order{})
+		if !errors.Is(err, broker.ErrUnexpectedEventType) {
+			t.Errorf("expected ErrUnexpectedEventType, got %v", err)
+		}
+
+		if _, err := broker.Events.NewEvent(ctx, "payments", "order.unknown", nil); !errors.Is(err, broker.ErrUnknownEventType) {
+			t.Errorf("expected ErrUnknownEventType, got %v", err)
+		}
+	})
+
+	for _, encoding := range []broker.Encoding{broker.EncodingCloudEventsBinary, broker.EncodingCloudEventsStructured} {
+		t.Run(string(encoding), func(t *testing.T) {
+			registry := broker.NewRegistry(broker.Schema{Type: broker.OrderPaidEvent, Version: 2})
+			if err := registry.SetEncoding(encoding); err != nil {
+				t.Fatal(err)
+			}
+
+			event, _ := registry.NewEvent(ctx, "payments", broker.OrderPaidEvent, broker.OrderPaid{OrderID: "42"})
+			msg, err := registry.Encode(ctx, event)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if encoding == broker.EncodingCloudEventsBinary {
+				if msg.Headers["ce-specversion"] != "1.0" || msg.Headers["ce-type"] != broker.OrderPaidEvent || msg.Headers["ce-id"] != event.ID {
+					t.Errorf("missing ce-* headers: %v", msg.Headers)
+				}
+			} else if msg.ContentType != broker.CloudEventsContentType {
+				t.Errorf("expected content type %s, got %s", broker.CloudEventsContentType, msg.ContentType)
+			}
+
+			var paid broker.OrderPaid
+			got, err := broker.Events.Decode(broker.Delivery{Message: msg}, broker.OrderPaidEvent, &paid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != event.ID || got.Version != 2 || got.Source != "payments" || got.CorrelationID != "corr-1" || !got.OccurredAt.Equal(event.OccurredAt) {
+				t.Errorf("unexpected event %+v", got)
+			}
+			if paid.OrderID != "42" {
+				t.Errorf("unexpected payload %+v", paid)
+			}
+		})
+	}
+}
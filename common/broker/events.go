@@ -1,6 +1,47 @@
 package broker
 
+import (
+	"encoding/json"
+
+	pb "github.com/juxue97/common/api"
+)
+
 const (
 	OrderCreatedEvent = "order.created"
 	OrderPaidEvent    = "order.paid"
 )
+
+// OrderPaid is the order.paid v2 payload. Version 1 was a partial pb.Order.
+type OrderPaid struct {
+	OrderID         string      `json:"orderId"`
+	CustomerID      string      `json:"customerId"`
+	ShippingAddress *pb.Address `json:"shippingAddress,omitempty"`
+	Shipping        int64       `json:"shipping,omitempty"`
+}
+
+// Events holds the schemas of every event in the system. The order.created
+// payload is a pb.Order.
+var Events = NewRegistry(
+	Schema{Type: OrderCreatedEvent, Version: 1},
+	Schema{
+		Type:    OrderPaidEvent,
+		Version: 2,
+		Upcasters: map[int]Upcaster{
+			1: upcastOrderPaidV1,
+		},
+	},
+)
+
+func upcastOrderPaidV1(payload json.RawMessage) (json.RawMessage, error) {
+	var o pb.Order
+	if err := json.Unmarshal(payload, &o); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(OrderPaid{
+		OrderID:         o.ID,
+		CustomerID:      o.CustomerID,
+		ShippingAddress: o.ShippingAddress,
+		Shipping:        o.Shipping,
+	})
+}
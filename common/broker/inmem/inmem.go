@@ -0,0 +1,489 @@
+package inmem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/juxue97/common/broker"
+)
+
+var errAlreadyAcknowledged = errors.New("delivery already acknowledged")
+
+type Broker struct {
+	sync.Mutex
+	exchanges map[string]*exchange
+	queues    map[string]*queue
+	generated int
+	closed    bool
+	done      chan struct{}
+}
+
+type exchange struct {
+	kind     string
+	bindings []binding
+}
+
+type binding struct {
+	queue      string
+	routingKey string
+}
+
+type queue struct {
+	name   string
+	opts   broker.QueueOptions
+	ready  []*message
+	notify chan struct{}
+}
+
+// subscription tracks the deliveries it handed out that are not yet settled,
+// which are requeued when it is released, as RabbitMQ does with the
+// unacknowledged deliveries of a channel that closes.
+type subscription struct {
+	unsettled map[*acknowledger]struct{}
+	released  bool
+}
+
+type message struct {
+	broker.Message
+	exchange    string
+	routingKey  string
+	redelivered bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		exchanges: map[string]*exchange{},
+		queues:    map[string]*queue{},
+		done:      make(chan struct{}),
+	}
+}
+
+func (b *Broker) DeclareExchange(name, kind string) error {
+	b.Lock()
+	defer b.Unlock()
+
+	if kind != broker.ExchangeDirect && kind != broker.ExchangeFanout {
+		return fmt.Errorf("unsupported exchange kind %q", kind)
+	}
+
+	if e, ok := b.exchanges[name]; ok {
+		if e.kind != kind {
+			return fmt.Errorf("exchange %q already declared as %s", name, e.kind)
+		}
+		return nil
+	}
+
+	b.exchanges[name] = &exchange{kind: kind}
+
+	return nil
+}
+
+func (b *Broker) DeclareQueue(name string, opts broker.QueueOptions) (string, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if name == "" {
+		b.generated++
+		name = fmt.Sprintf("amq.gen-%d", b.generated)
+	}
+
+	if _, ok := b.queues[name]; !ok {
+		b.queues[name] = &queue{name: name, opts: opts, notify: make(chan struct{}, 1)}
+	}
+
+	return name, nil
+}
+
+func (b *Broker) BindQueue(queue, routingKey, exchange string) error {
+	b.Lock()
+	defer b.Unlock()
+
+	e, ok := b.exchanges[exchange]
+	if !ok {
+		return fmt.Errorf("%w: %s", broker.ErrExchangeNotFound, exchange)
+	}
+
+	if _, ok := b.queues[queue]; !ok {
+		return fmt.Errorf("%w: %s", broker.ErrQueueNotFound, queue)
+	}
+
+	for _, bd := range e.bindings {
+		if bd.queue == queue && bd.routingKey == routingKey {
+			return nil
+		}
+	}
+
+	e.bindings = append(e.bindings, binding{queue: queue, routingKey: routingKey})
+
+	return nil
+}
+
+func (b *Broker) Publish(ctx context.Context, exchange, routingKey string, msg broker.Message) error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		return broker.ErrClosed
+	}
+
+	return b.route(exchange, routingKey, msg)
+}
+
+// route must be called with the lock held. Like a mandatory publish to
+// RabbitMQ, a message no queue accepts fails with ErrUnroutable.
+func (b *Broker) route(exchange, routingKey string, msg broker.Message) error {
+	if exchange == "" {
+		q, ok := b.queues[routingKey]
+		if !ok {
+			return fmt.Errorf("%w: no queue %s", broker.ErrUnroutable, routingKey)
+		}
+		b.enqueue(q, &message{Message: copyMessage(msg), exchange: exchange, routingKey: routingKey})
+		return nil
+	}
+
+	e, ok := b.exchanges[exchange]
+	if !ok {
+		return fmt.Errorf("%w: %s", broker.ErrExchangeNotFound, exchange)
+	}
+
+	routed := false
+	for _, bd := range e.bindings {
+		if e.kind == broker.ExchangeDirect && bd.routingKey != routingKey {
+			continue
+		}
+		if q, ok := b.queues[bd.queue]; ok {
+			b.enqueue(q, &message{Message: copyMessage(msg), exchange: exchange, routingKey: routingKey})
+			routed = true
+		}
+	}
+
+	if !routed {
+		return fmt.Errorf("%w: %s has no binding for %q", broker.ErrUnroutable, exchange, routingKey)
+	}
+
+	return nil
+}
+
+func (b *Broker) Subscribe(ctx context.Context, queue string, opts broker.SubscribeOptions) (<-chan broker.Delivery, func() error, error) {
+	b.Lock()
+	q, ok := b.queues[queue]
+	closed := b.closed
+	b.Unlock()
+
+	if closed {
+		return nil, nil, broker.ErrClosed
+	}
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", broker.ErrQueueNotFound, queue)
+	}
+
+	sub := &subscription{unsettled: map[*acknowledger]struct{}{}}
+
+	// each unacknowledged delivery holds a slot until it is acked or nacked
+	var slots chan struct{}
+	if opts.Prefetch > 0 {
+		slots = make(chan struct{}, opts.Prefetch)
+	}
+
+	out := make(chan broker.Delivery)
+	go func() {
+		defer close(out)
+		for {
+			if slots != nil {
+				select {
+				case slots <- struct{}{}:
+				case <-ctx.Done():
+					return
+				case <-b.done:
+					return
+				}
+			}
+
+			release := func() {
+				if slots != nil {
+					<-slots
+				}
+			}
+
+			m, ok := b.next(ctx, q)
+			if !ok {
+				release()
+				return
+			}
+
+			b.Lock()
+			d := b.delivery(q, m, sub, release)
+			b.Unlock()
+
+			select {
+			case out <- d:
+			case <-ctx.Done():
+				d.Nack(true)
+				return
+			case <-b.done:
+				return
+			}
+		}
+	}()
+
+	return out, func() error { return b.release(sub) }, nil
+}
+
+// release requeues the deliveries of sub that were not settled, after which
+// settling them fails with ErrClosed.
+func (b *Broker) release(sub *subscription) error {
+	b.Lock()
+	sub.released = true
+	var pending []*acknowledger
+	for a := range sub.unsettled {
+		a.done = true
+		a.settled()
+		pending = append(pending, a)
+	}
+	b.Unlock()
+
+	for _, a := range pending {
+		b.requeue(a.queue, a.message)
+	}
+
+	return nil
+}
+
+func (b *Broker) Get(ctx context.Context, queue string) (broker.Delivery, bool, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		return broker.Delivery{}, false, broker.ErrClosed
+	}
+
+	q, ok := b.queues[queue]
+	if !ok {
+		return broker.Delivery{}, false, fmt.Errorf("%w: %s", broker.ErrQueueNotFound, queue)
+	}
+
+	if len(q.ready) == 0 {
+		return broker.Delivery{}, false, nil
+	}
+
+	m := q.ready[0]
+	q.ready = q.ready[1:]
+
+	return b.delivery(q, m, nil, nil), true, nil
+}
+
+// delivery must be called with the lock held.
+func (b *Broker) delivery(q *queue, m *message, sub *subscription, release func()) broker.Delivery {
+	a := &acknowledger{broker: b, queue: q, message: m, sub: sub, release: release}
+	if sub != nil {
+		sub.unsettled[a] = struct{}{}
+	}
+
+	return broker.Delivery{
+		Message:      copyMessage(m.Message),
+		Exchange:     m.exchange,
+		RoutingKey:   m.routingKey,
+		Redelivered:  m.redelivered,
+		Acknowledger: a,
+	}
+}
+
+// Ready returns the number of messages waiting in queue to be delivered.
+func (b *Broker) Ready(queue string) int {
+	b.Lock()
+	defer b.Unlock()
+
+	if q, ok := b.queues[queue]; ok {
+		return len(q.ready)
+	}
+	return 0
+}
+
+func (b *Broker) Healthy() bool {
+	b.Lock()
+	defer b.Unlock()
+
+	return !b.closed
+}
+
+func (b *Broker) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+
+	return nil
+}
+
+func (b *Broker) next(ctx context.Context, q *queue) (*message, bool) {
+	for {
+		b.Lock()
+		if len(q.ready) > 0 {
+			m := q.ready[0]
+			q.ready = q.ready[1:]
+			if len(q.ready) > 0 {
+				q.signal()
+			}
+			b.Unlock()
+			return m, true
+		}
+		b.Unlock()
+
+		select {
+		case <-q.notify:
+		case <-ctx.Done():
+			return nil, false
+		case <-b.done:
+			return nil, false
+		}
+	}
+}
+
+func (b *Broker) requeue(q *queue, m *message) {
+	b.Lock()
+	defer b.Unlock()
+
+	m.redelivered = true
+	q.ready = append([]*message{m}, q.ready...)
+	q.signal()
+}
+
+// deadLetter must be called with the lock held.
+func (b *Broker) deadLetter(q *queue, m *message) {
+	exchange, ok := q.opts.Args["x-dead-letter-exchange"].(string)
+	if !ok {
+		return
+	}
+
+	routingKey := m.routingKey
+	if key, ok := q.opts.Args["x-dead-letter-routing-key"].(string); ok {
+		routingKey = key
+	}
+
+	b.route(exchange, routingKey, m.Message)
+}
+
+// enqueue must be called with the lock held.
+func (b *Broker) enqueue(q *queue, m *message) {
+	q.ready = append(q.ready, m)
+	q.signal()
+
+	if ttl := q.ttl(); ttl > 0 {
+		time.AfterFunc(ttl, func() { b.expire(q, m) })
+	}
+}
+
+func (b *Broker) expire(q *queue, m *message) {
+	b.Lock()
+	defer b.Unlock()
+
+	for i, ready := range q.ready {
+		if ready == m {
+			q.ready = append(q.ready[:i], q.ready[i+1:]...)
+			b.deadLetter(q, m)
+			return
+		}
+	}
+}
+
+func (q *queue) ttl() time.Duration {
+	switch ms := q.opts.Args["x-message-ttl"].(type) {
+	case int:
+		return time.Duration(ms) * time.Millisecond
+	case int32:
+		return time.Duration(ms) * time.Millisecond
+	case int64:
+		return time.Duration(ms) * time.Millisecond
+	}
+	return 0
+}
+
+func (q *queue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+type acknowledger struct {
+	broker  *Broker
+	queue   *queue
+	message *message
+	sub     *subscription
+	release func()
+	done    bool
+}
+
+func (a *acknowledger) Ack() error {
+	a.broker.Lock()
+	defer a.broker.Unlock()
+
+	if a.sub != nil && a.sub.released {
+		return broker.ErrClosed
+	}
+	if a.done {
+		return errAlreadyAcknowledged
+	}
+	a.done = true
+	a.settled()
+
+	return nil
+}
+
+func (a *acknowledger) Nack(requeue bool) error {
+	a.broker.Lock()
+	if a.sub != nil && a.sub.released {
+		a.broker.Unlock()
+		return broker.ErrClosed
+	}
+	if a.done {
+		a.broker.Unlock()
+		return errAlreadyAcknowledged
+	}
+	a.done = true
+	a.settled()
+
+	if !requeue {
+		a.broker.deadLetter(a.queue, a.message)
+		a.broker.Unlock()
+		return nil
+	}
+	a.broker.Unlock()
+
+	a.broker.requeue(a.queue, a.message)
+
+	return nil
+}
+
+// settled must be called with the lock held.
+func (a *acknowledger) settled() {
+	if a.sub != nil {
+		delete(a.sub.unsettled, a)
+	}
+	if a.release != nil {
+		a.release()
+	}
+}
+
+func copyMessage(msg broker.Message) broker.Message {
+	headers := make(map[string]interface{}, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+
+	body := make([]byte, len(msg.Body))
+	copy(body, msg.Body)
+
+	return broker.Message{
+		MessageID:   msg.MessageID,
+		ContentType: msg.ContentType,
+		Headers:     headers,
+		Body:        body,
+	}
+}
@@ -0,0 +1,159 @@
+package inmem
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/broker"
+)
+
+func receive(t *testing.T, deliveries <-chan broker.Delivery) broker.Delivery {
+	t.Helper()
+
+	select {
+	case d := <-deliveries:
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+	return broker.Delivery{}
+}
+
+func TestBroker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("fanout delivers to every bound queue", func(t *testing.T) {
+		b := NewBroker()
+		if err := b.DeclareExchange("paid", broker.ExchangeFanout); err != nil {
+			t.Fatal(err)
+		}
+
+		q1, _ := b.DeclareQueue("", broker.QueueOptions{})
+		q2, _ := b.DeclareQueue("", broker.QueueOptions{})
+		b.BindQueue(q1, "", "paid")
+		b.BindQueue(q2, "", "paid")
+
+		if err := b.Publish(ctx, "paid", "", broker.Message{Body: []byte("hello")}); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, q := range []string{q1, q2} {
+			deliveries, _, err := b.Subscribe(ctx, q, broker.SubscribeOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := receive(t, deliveries)
+			if string(d.Body) != "hello" {
+				t.Errorf("expected body hello, got %s", d.Body)
+			}
+			d.Ack()
+		}
+	})
+
+	t.Run("direct routes by key and default exchange by queue name", func(t *testing.T) {
+		b := NewBroker()
+		b.DeclareExchange("orders", broker.ExchangeDirect)
+		b.DeclareQueue("created", broker.QueueOptions{Durable: true})
+		b.DeclareQueue("other", broker.QueueOptions{Durable: true})
+		b.BindQueue("created", "created", "orders")
+		b.BindQueue("other", "other", "orders")
+
+		b.Publish(ctx, "orders", "created", broker.Message{Body: []byte("1")})
+		b.Publish(ctx, "", "created", broker.Message{Body: []byte("2")})
+
+		if got := b.Ready("created"); got != 2 {
+			t.Errorf("expected 2 messages in created, got %d", got)
+		}
+		if got := b.Ready("other"); got != 0 {
+			t.Errorf("expected 0 messages in other, got %d", got)
+		}
+
+		if err := b.Publish(ctx, "missing", "", broker.Message{}); err == nil {
+			t.Error("expected error publishing to unknown exchange")
+		}
+		if err := b.Publish(ctx, "orders", "unbound", broker.Message{}); !errors.Is(err, broker.ErrUnroutable) {
+			t.Errorf("expected ErrUnroutable for unbound key, got %v", err)
+		}
+		if err := b.Publish(ctx, "", "missing", broker.Message{}); !errors.Is(err, broker.ErrUnroutable) {
+			t.Errorf("expected ErrUnroutable for unknown queue, got %v", err)
+		}
+	})
+
+	t.Run("nack with requeue redelivers", func(t *testing.T) {
+		b := NewBroker()
+		b.DeclareQueue("work", broker.QueueOptions{})
+		b.Publish(ctx, "", "work", broker.Message{Body: []byte("job")})
+
+		deliveries, _, _ := b.Subscribe(ctx, "work", broker.SubscribeOptions{})
+
+		d := receive(t, deliveries)
+		if d.Redelivered {
+			t.Error("first delivery should not be marked redelivered")
+		}
+		if err := d.Nack(true); err != nil {
+			t.Fatal(err)
+		}
+
+		d = receive(t, deliveries)
+		if !d.Redelivered {
+			t.Error("expected redelivered flag")
+		}
+		if err := d.Ack(); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Ack(); err == nil {
+			t.Error("expected error acking twice")
+		}
+	})
+
+	t.Run("nack without requeue dead-letters", func(t *testing.T) {
+		b := NewBroker()
+		b.DeclareExchange("dlx", broker.ExchangeFanout)
+		b.DeclareQueue("dlq", broker.QueueOptions{})
+		b.BindQueue("dlq", "", "dlx")
+		b.DeclareQueue("work", broker.QueueOptions{Args: map[string]interface{}{
+			"x-dead-letter-exchange": "dlx",
+		}})
+		b.Publish(ctx, "", "work", broker.Message{Body: []byte("job")})
+
+		deliveries, _, _ := b.Subscribe(ctx, "work", broker.SubscribeOptions{})
+		receive(t, deliveries).Nack(false)
+
+		if got := b.Ready("dlq"); got != 1 {
+			t.Errorf("expected message in dlq, got %d", got)
+		}
+	})
+	t.Run("release requeues what was not settled", func(t *testing.T) {
+		b := NewBroker()
+		b.DeclareQueue("work", broker.QueueOptions{})
+		b.Publish(ctx, "", "work", broker.Message{Body: []byte("1")})
+		b.Publish(ctx, "", "work", broker.Message{Body: []byte("2")})
+
+		subCtx, stop := context.WithCancel(ctx)
+		deliveries, release, _ := b.Subscribe(subCtx, "work", broker.SubscribeOptions{})
+		acked := receive(t, deliveries)
+		unsettled := receive(t, deliveries)
+
+		stop()
+		for range deliveries {
+		}
+
+		// settling still works until the subscription is released
+		if err := acked.Ack(); err != nil {
+			t.Fatal(err)
+		}
+		if err := release(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := unsettled.Ack(); !errors.Is(err, broker.ErrClosed) {
+			t.Errorf("expected ErrClosed acking after release, got %v", err)
+		}
+		if got := b.Ready("work"); got != 1 {
+			t.Errorf("expected only the unsettled message requeued, got %d", got)
+		}
+	})
+}
@@ -2,139 +2,343 @@ package broker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/juxue97/common"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.opentelemetry.io/otel"
 )
 
 const (
-	maxRetryCount = 3
-	dlq           = "dlq_main"
+	dlq = "dlq_main"
+	dlx = "dlx_main"
+
+	defaultConfirmTimeout = 5 * time.Second
 )
 
-func Connect(user, pass, host, port string) (*amqp.Channel, func() error) {
+// Connect dials RabbitMQ, retrying with backoff, and declares the shared
+// topology. The connection is re-established automatically if it drops.
+func Connect(ctx context.Context, user, pass, host, port string) (*RabbitMQ, error) {
 	address := fmt.Sprintf("amqp://%s:%s@%s:%s", user, pass, host, port)
 
-	conn, err := amqp.Dial(address)
+	r := &RabbitMQ{conn: newConnection(address, defaultReconnect), confirmTimeout: defaultConfirmTimeout}
+
+	err := common.Retry(ctx, defaultReconnect, func(error) bool { return true }, func(context.Context) error {
+		return r.conn.dial()
+	})
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	ch, err := conn.Channel()
-	if err != nil {
-		log.Fatal(err)
+	if err := DeclareTopology(r); err != nil {
+		r.Close()
+		return nil, err
 	}
 
-	err = ch.ExchangeDeclare(OrderCreatedEvent, "direct", true, false, false, false, nil)
-	if err != nil {
-		log.Fatal(err)
+	return r, nil
+}
+
+type RabbitMQ struct {
+	conn           *connection
+	tags           atomic.Uint64
+	confirmTimeout time.Duration
+}
+
+// Publish sends msg as a mandatory publish and waits for the broker to
+// confirm it. A publish nacked because the channel dropped is retried on the
+// next one, so consumers may see the same message id twice.
+func (r *RabbitMQ) Publish(ctx context.Context, exchange, routingKey string, msg Message) error {
+	if msg.MessageID == "" {
+		msg.MessageID = uuid.NewString()
 	}
 
-	err = ch.ExchangeDeclare(OrderPaidEvent, "fanout", true, false, false, false, nil)
-	if err != nil {
-		log.Fatal(err)
+	ctx, cancel := context.WithTimeout(ctx, r.confirmTimeout)
+	defer cancel()
+
+	for {
+		ch, returns, err := r.conn.current(ctx, r.conn.publisher)
+		if err != nil {
+			return r.publishError(ctx, err)
+		}
+
+		returns.track(msg.MessageID)
+		acked, err := r.publish(ctx, ch, exchange, routingKey, msg)
+		returned := returns.untrack(msg.MessageID)
+
+		switch {
+		case errors.Is(err, amqp.ErrClosed), err == nil && !acked && ch.IsClosed():
+			// the channel went away under us, wait for it to be reopened and retry
+			if err := r.conn.replaced(ctx, r.conn.publisher, ch); err != nil {
+				return r.publishError(ctx, err)
+			}
+			continue
+		case err != nil:
+			return r.publishError(ctx, err)
+		case !acked:
+			return fmt.Errorf("publishing to %q: %w", exchange, ErrNacked)
+		case returned != nil:
+			return fmt.Errorf("publishing to %q with key %q: %w: %s", exchange, routingKey, ErrUnroutable, returned.ReplyText)
+		}
+
+		return nil
 	}
+}
 
-	err = createDLQAndDLX(ch)
+func (r *RabbitMQ) publish(ctx context.Context, ch *amqp.Channel, exchange, routingKey string, msg Message) (bool, error) {
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, true, false, amqp.Publishing{
+		MessageId:    msg.MessageID,
+		ContentType:  msg.ContentType,
+		Headers:      msg.Headers,
+		Body:         msg.Body,
+		DeliveryMode: amqp.Persistent,
+	})
 	if err != nil {
-		log.Fatal(err)
+		return false, err
 	}
 
-	return ch, conn.Close
+	return confirm.WaitContext(ctx)
 }
 
-func HandleRetry(ch *amqp.Channel, d *amqp.Delivery) error {
-	if d.Headers == nil {
-		d.Headers = amqp.Table{}
+func (r *RabbitMQ) publishError(ctx context.Context, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		return ErrConfirmTimeout
 	}
+	return err
+}
 
-	retryCount, ok := d.Headers["x-retry-count"].(int64)
-	if !ok {
-		retryCount = 0
+// SetConfirmTimeout bounds how long Publish waits for the broker to confirm
+// a message, including any wait for a reconnect.
+func (r *RabbitMQ) SetConfirmTimeout(d time.Duration) {
+	if d > 0 {
+		r.confirmTimeout = d
 	}
+}
 
-	retryCount++
-	d.Headers["x-retry-count"] = retryCount
+// Subscribe keeps consuming from queue across reconnects until ctx is done.
+// Each subscription consumes on a channel of its own, which stays open once
+// consuming stops so the deliveries handed out can still be acked, until
+// release closes it.
+func (r *RabbitMQ) Subscribe(ctx context.Context, queue string, opts SubscribeOptions) (<-chan Delivery, func() error, error) {
+	tag := fmt.Sprintf("%s-%d", queue, r.tags.Add(1))
 
-	log.Printf("Message: %s, Retry count: %d", d.Body, retryCount)
+	ch, messages, err := r.consume(ctx, queue, tag, opts.Prefetch)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if retryCount >= maxRetryCount {
-		log.Printf("moving message to DQL %s", dlq)
+	var mu sync.Mutex
+	current := ch
+	release := func() error {
+		mu.Lock()
+		defer mu.Unlock()
 
-		return ch.PublishWithContext(context.Background(), "", dlq, false, false, amqp.Publishing{
-			ContentType:  "application/json",
-			Headers:      d.Headers,
-			Body:         d.Body,
-			DeliveryMode: amqp.Persistent,
-		})
+		if err := current.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			return err
+		}
+		return nil
 	}
 
-	time.Sleep(time.Second * time.Duration(retryCount))
-	return ch.PublishWithContext(context.Background(), d.Exchange, d.RoutingKey, false, false, amqp.Publishing{
-		ContentType:  "application/json",
-		Headers:      d.Headers,
-		Body:         d.Body,
-		DeliveryMode: amqp.Persistent,
-	})
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for {
+			if !r.deliver(ctx, ch, tag, messages, out) {
+				return
+			}
+
+			for attempt := 1; ; attempt++ {
+				ch, messages, err = r.consume(ctx, queue, tag, opts.Prefetch)
+				if err == nil {
+					mu.Lock()
+					current = ch
+					mu.Unlock()
+					break
+				}
+				if ctx.Err() != nil || r.conn.closed() {
+					return
+				}
+
+				log.Printf("failed to resume consuming %s: %v", queue, err)
+				select {
+				case <-time.After(r.conn.backoff.Backoff(attempt)):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, release, nil
 }
 
-func createDLQAndDLX(ch *amqp.Channel) error {
-	q, err := ch.QueueDeclare(
-		"main_queue", // name
-		true,         // durable
-		false,        // delete when unused
-		false,        // exclusive
-		false,        // no-wait
-		nil,          // arguments
-	)
-	if err != nil {
-		return err
+// Get fetches one message from queue on a channel shared by gets only, so
+// the channel exception a missing queue raises leaves publishing and
+// consumers alone.
+func (r *RabbitMQ) Get(ctx context.Context, queue string) (Delivery, bool, error) {
+	for {
+		ch, _, err := r.conn.current(ctx, r.conn.getter)
+		if err != nil {
+			return Delivery{}, false, err
+		}
+
+		d, ok, err := ch.Get(r.conn.resolve(queue), false)
+		if errors.Is(err, amqp.ErrClosed) {
+			if err := r.conn.replaced(ctx, r.conn.getter, ch); err != nil {
+				return Delivery{}, false, err
+			}
+			continue
+		}
+		if err != nil || !ok {
+			return Delivery{}, false, err
+		}
+
+		return fromAMQP(d), true, nil
 	}
+}
 
-	// Declare DLX
-	dlx := "dlx_main"
-	err = ch.ExchangeDeclare(
-		dlx,      // name
-		"fanout", // type
-		true,     // durable
-		false,    // auto-deleted
-		false,    // internal
-		false,    // no-wait
-		nil,      // arguments
-	)
+func (r *RabbitMQ) consume(ctx context.Context, queue, tag string, prefetch int) (*amqp.Channel, <-chan amqp.Delivery, error) {
+	ch, err := r.conn.open(ctx)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	if prefetch > 0 {
+		if err := ch.Qos(prefetch, 0, false); err != nil {
+			ch.Close()
+			return nil, nil, err
+		}
 	}
 
-	// Bind main queue to DLX
-	err = ch.QueueBind(
-		q.Name, // queue name
-		"",     // routing key
-		dlx,    // exchange
-		false,
-		nil,
-	)
+	messages, err := ch.Consume(r.conn.resolve(queue), tag, false, false, false, false, nil)
 	if err != nil {
+		ch.Close()
+		return nil, nil, err
+	}
+
+	return ch, messages, nil
+}
+
+// deliver forwards messages to out until the channel closes, returning true
+// if consuming should resume on a new channel. Once ctx is done it cancels
+// the consumer but leaves the channel open for the deliveries already handed
+// out to be settled on.
+func (r *RabbitMQ) deliver(ctx context.Context, ch *amqp.Channel, tag string, messages <-chan amqp.Delivery, out chan<- Delivery) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			ch.Cancel(tag, false)
+			// drain deliveries already in flight so they are requeued
+			for d := range messages {
+				d.Nack(false, true)
+			}
+			return false
+		case d, ok := <-messages:
+			if !ok {
+				return !r.conn.closed()
+			}
+			select {
+			case out <- fromAMQP(d):
+			case <-ctx.Done():
+				d.Nack(false, true)
+			}
+		}
+	}
+}
+
+func (r *RabbitMQ) DeclareExchange(name, kind string) error {
+	d := declaration{kind: declareExchange, name: name, exchangeKind: kind}
+	return r.declare(d)
+}
+
+func (r *RabbitMQ) DeclareQueue(name string, opts QueueOptions) (string, error) {
+	var q amqp.Queue
+	err := r.conn.withChannel(context.Background(), func(ch *amqp.Channel) error {
+		var err error
+		q, err = ch.QueueDeclare(name, opts.Durable, opts.AutoDelete, opts.Exclusive, false, opts.Args)
 		return err
+	})
+	if err != nil {
+		return "", err
 	}
 
-	// Declare DLQ
-	_, err = ch.QueueDeclare(
-		dlq,   // name
-		true,  // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
-	)
+	r.conn.record(declaration{kind: declareQueue, name: q.Name, opts: opts, generated: name == ""})
+
+	return q.Name, nil
+}
+
+func (r *RabbitMQ) BindQueue(queue, routingKey, exchange string) error {
+	d := declaration{kind: bindQueue, name: queue, routingKey: routingKey, exchange: exchange}
+	return r.declare(d)
+}
+
+func (r *RabbitMQ) declare(d declaration) error {
+	err := r.conn.withChannel(context.Background(), func(ch *amqp.Channel) error {
+		return r.conn.apply(ch, d)
+	})
 	if err != nil {
 		return err
 	}
 
-	return err
+	r.conn.record(d)
+
+	return nil
+}
+
+func (r *RabbitMQ) Healthy() bool {
+	return r.conn.health().Connected
+}
+
+// Ping reports an error while the connection is down, for readiness checks.
+func (r *RabbitMQ) Ping(ctx context.Context) error {
+	status := r.conn.health()
+	if status.Connected {
+		return nil
+	}
+	if status.LastError != nil {
+		return fmt.Errorf("rabbitmq disconnected: %w", status.LastError)
+	}
+	return errors.New("rabbitmq disconnected")
+}
+
+func (r *RabbitMQ) Status() Status {
+	return r.conn.health()
+}
+
+func (r *RabbitMQ) Close() error {
+	return r.conn.close()
+}
+
+type amqpAcknowledger struct {
+	d amqp.Delivery
+}
+
+func (a amqpAcknowledger) Ack() error {
+	return a.d.Ack(false)
+}
+
+func (a amqpAcknowledger) Nack(requeue bool) error {
+	return a.d.Nack(false, requeue)
+}
+
+func fromAMQP(d amqp.Delivery) Delivery {
+	return Delivery{
+		Message: Message{
+			MessageID:   d.MessageId,
+			ContentType: d.ContentType,
+			Headers:     d.Headers,
+			Body:        d.Body,
+		},
+		Exchange:     d.Exchange,
+		RoutingKey:   d.RoutingKey,
+		Redelivered:  d.Redelivered,
+		Acknowledger: amqpAcknowledger{d: d},
+	}
 }
 
 type AmqpHeaderCarrier map[string]interface{}
@@ -0,0 +1,196 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	retryCountHeader       = "x-retry-count"
+	originExchangeHeader   = "x-origin-exchange"
+	originRoutingKeyHeader = "x-origin-routing-key"
+	originQueueHeader      = "x-origin-queue"
+	lastErrorHeader        = "x-last-error"
+	failedAtHeader         = "x-failed-at"
+)
+
+type RetryPolicy struct {
+	// MaxAttempts is the number of deliveries before a message is moved to the DLQ.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Second,
+	MaxDelay:    time.Minute,
+}
+
+// Delay returns the wait before the given retry, doubling from BaseDelay.
+// Delays are not jittered since every distinct delay needs its own queue.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if attempt <= 0 {
+		return 0
+	}
+
+	delay := p.BaseDelay << (attempt - 1)
+	if p.MaxDelay > 0 && (delay > p.MaxDelay || delay <= 0) {
+		delay = p.MaxDelay
+	}
+
+	return delay
+}
+
+func retryName(delay time.Duration) string {
+	return fmt.Sprintf("retry.%s", delay)
+}
+
+// Retrier schedules failed deliveries from queue for redelivery through
+// per-delay retry queues. Each retry queue holds messages for its TTL and
+// then dead-letters them through the default exchange, keyed by the origin
+// queue, so a retried fanout event only reaches the consumer that failed it.
+type Retrier struct {
+	publisher Publisher
+	queue     string
+	policy    RetryPolicy
+}
+
+func NewRetrier(b Broker, queue string, policy RetryPolicy) (*Retrier, error) {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+
+	if err := declareRetryQueues(b, policy); err != nil {
+		return nil, err
+	}
+
+	return &Retrier{publisher: b, queue: queue, policy: policy}, nil
+}
+
+// declareRetryQueues declares an exchange and TTL queue for each delay of
+// policy, shared by every consumer using the same delays.
+func declareRetryQueues(d Declarer, policy RetryPolicy) error {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+
+	for attempt := 1; attempt < policy.MaxAttempts; attempt++ {
+		delay := policy.Delay(attempt)
+		name := retryName(delay)
+
+		if err := d.DeclareExchange(name, ExchangeFanout); err != nil {
+			return err
+		}
+
+		_, err := d.DeclareQueue(name, QueueOptions{
+			Durable: true,
+			Args: map[string]interface{}{
+				"x-message-ttl":          delay.Milliseconds(),
+				"x-dead-letter-exchange": "",
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := d.BindQueue(name, "", name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HandleRetry republishes d to the next retry queue, or to the DLQ once the
+// policy is exhausted, recording cause in its headers, and acks d. If the
+// republish fails d is requeued once the retry delay has passed.
+func (r *Retrier) HandleRetry(ctx context.Context, d Delivery, cause error) error {
+	msg, retryCount := r.failed(d, cause)
+	delay := r.policy.Delay(int(retryCount))
+
+	if retryCount >= int64(r.policy.MaxAttempts) {
+		log.Printf("moving message to DLQ %s after %d attempts: %v", dlq, retryCount, cause)
+		return r.settle(ctx, d, delay, r.deadLetter(ctx, msg))
+	}
+
+	log.Printf("retrying message from %s in %s, retry count: %d", r.queue, delay, retryCount)
+
+	return r.settle(ctx, d, delay, r.publisher.Publish(ctx, retryName(delay), r.queue, msg))
+}
+
+// Reject moves d straight to the DLQ, for failures retrying cannot fix such
+// as a payload that does not decode.
+func (r *Retrier) Reject(ctx context.Context, d Delivery, cause error) error {
+	msg, retryCount := r.failed(d, cause)
+
+	log.Printf("rejecting message from %s to DLQ %s: %v", r.queue, dlq, cause)
+
+	return r.settle(ctx, d, r.policy.Delay(int(retryCount)), r.deadLetter(ctx, msg))
+}
+
+// failed copies d with its retry count incremented and the failure recorded.
+func (r *Retrier) failed(d Delivery, cause error) (Message, int64) {
+	headers := make(map[string]interface{}, len(d.Headers)+6)
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+
+	retryCount, ok := headers[retryCountHeader].(int64)
+	if !ok {
+		retryCount = 0
+	}
+
+	retryCount++
+	headers[retryCountHeader] = retryCount
+
+	if _, ok := headers[originExchangeHeader]; !ok {
+		headers[originExchangeHeader] = d.Exchange
+		headers[originRoutingKeyHeader] = d.RoutingKey
+	}
+	headers[originQueueHeader] = r.queue
+	headers[failedAtHeader] = time.Now().UTC().Format(time.RFC3339)
+	if cause != nil {
+		headers[lastErrorHeader] = cause.Error()
+	}
+
+	return Message{
+		MessageID:   d.MessageID,
+		ContentType: d.ContentType,
+		Headers:     headers,
+		Body:        d.Body,
+	}, retryCount
+}
+
+func (r *Retrier) deadLetter(ctx context.Context, msg Message) error {
+	if msg.MessageID == "" {
+		msg.MessageID = uuid.NewString()
+	}
+
+	return r.publisher.Publish(ctx, "", dlq, msg)
+}
+
+// settle acks d once it has been republished, or requeues it after delay if
+// that failed, so a publish outage doesn't redeliver it in a tight loop.
+func (r *Retrier) settle(ctx context.Context, d Delivery, delay time.Duration, err error) error {
+	if err != nil {
+		if delay <= 0 {
+			delay = DefaultRetryPolicy.BaseDelay
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+
+		if nackErr := d.Nack(true); nackErr != nil {
+			log.Printf("failed to requeue message: %v", nackErr)
+		}
+		return err
+	}
+
+	return d.Ack()
+}
@@ -0,0 +1,107 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/broker"
+	"github.com/juxue97/common/broker/inmem"
+)
+
+func TestRetrier(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := inmem.NewBroker()
+	if err := broker.DeclareTopology(b); err != nil {
+		t.Fatal(err)
+	}
+	b.DeclareQueue("work", broker.QueueOptions{Durable: true})
+
+	policy := broker.RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second}
+	retrier, err := broker.NewRetrier(b, "work", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.Publish(ctx, "", "work", broker.Message{Body: []byte("job")})
+
+	deliveries, _, err := b.Subscribe(ctx, "work", broker.SubscribeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := int64(0); attempt < 2; attempt++ {
+		select {
+		case d := <-deliveries:
+			if count, _ := d.Headers["x-retry-count"].(int64); count != attempt {
+				t.Fatalf("expected retry count %d, got %d", attempt, count)
+			}
+			if err := retrier.HandleRetry(ctx, d, errors.New("boom")); err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for attempt %d", attempt+1)
+		}
+	}
+
+	select {
+	case d := <-deliveries:
+		if err := retrier.HandleRetry(ctx, d, errors.New("boom")); err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for final attempt")
+	}
+
+	if got := b.Ready("dlq_main"); got != 1 {
+		t.Errorf("expected message in dlq, got %d", got)
+	}
+	if got := b.Ready("work"); got != 0 {
+		t.Errorf("expected work queue to be empty, got %d", got)
+	}
+}
+
+// unpublishable fails every publish, as during a broker-side outage.
+type unpublishable struct {
+	*inmem.Broker
+}
+
+func (b unpublishable) Publish(ctx context.Context, exchange, routingKey string, msg broker.Message) error {
+	return broker.ErrNacked
+}
+
+func TestRetrierWaitsBeforeRequeueing(t *testing.T) {
+	ctx := context.Background()
+
+	b := inmem.NewBroker()
+	if err := broker.DeclareTopology(b); err != nil {
+		t.Fatal(err)
+	}
+	b.DeclareQueue("work", broker.QueueOptions{Durable: true})
+
+	policy := broker.RetryPolicy{MaxAttempts: 3, BaseDelay: 50 * time.Millisecond, MaxDelay: time.Second}
+	retrier, err := broker.NewRetrier(unpublishable{b}, "work", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.Publish(ctx, "", "work", broker.Message{Body: []byte("job")})
+	d, _, err := b.Get(ctx, "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := retrier.HandleRetry(ctx, d, errors.New("boom")); !errors.Is(err, broker.ErrNacked) {
+		t.Fatalf("expected the publish error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < policy.BaseDelay {
+		t.Errorf("expected the requeue to wait %s, took %s", policy.BaseDelay, elapsed)
+	}
+	if got := b.Ready("work"); got != 1 {
+		t.Errorf("expected the message requeued, got %d", got)
+	}
+}
@@ -0,0 +1,187 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidTopology = errors.New("invalid topology")
+
+// Topology describes the exchanges, queues and bindings a service relies on.
+// Declaring is idempotent, so every replica applies its topology at startup.
+type Topology struct {
+	Exchanges []ExchangeSpec
+	Queues    []QueueSpec
+}
+
+type ExchangeSpec struct {
+	Name string
+	Kind string
+}
+
+type QueueSpec struct {
+	Name    string
+	Options QueueOptions
+	// DeadLetter routes messages the broker rejects or expires to the DLQ.
+	DeadLetter bool
+	Bindings   []BindingSpec
+	// Retry declares the retry queues used by the queue's consumer.
+	Retry *RetryPolicy
+}
+
+type BindingSpec struct {
+	Exchange   string
+	RoutingKey string
+}
+
+// SharedTopology is declared by Connect for every service.
+var SharedTopology = Topology{
+	Exchanges: []ExchangeSpec{
+		{Name: OrderCreatedEvent, Kind: ExchangeDirect},
+		{Name: OrderPaidEvent, Kind: ExchangeFanout},
+		{Name: dlx, Kind: ExchangeFanout},
+	},
+	Queues: []QueueSpec{
+		// order publishes straight to the queue, so it exists before payment starts
+		{Name: OrderCreatedEvent, Options: QueueOptions{Durable: true}},
+		{Name: dlq, Options: QueueOptions{Durable: true}, Bindings: []BindingSpec{{Exchange: dlx}}},
+	},
+}
+
+// PaymentTopology is the topology of the payment service, consuming
+// order.created.
+func PaymentTopology(retry RetryPolicy) Topology {
+	return Topology{
+		Queues: []QueueSpec{
+			{Name: OrderCreatedEvent, Options: QueueOptions{Durable: true}, Retry: &retry},
+		},
+	}
+}
+
+// SubscriberTopology gives service its own durable queue on the exchange of
+// event, named by ServiceQueue. Replicas of the service compete for the
+// messages on that queue, so each event is handled once per service, and
+// events published while the service is down wait for it.
+func SubscriberTopology(service, event string, retry RetryPolicy) Topology {
+	return Topology{
+		Queues: []QueueSpec{{
+			Name:       ServiceQueue(service, event),
+			Options:    QueueOptions{Durable: true},
+			DeadLetter: true,
+			Bindings:   []BindingSpec{{Exchange: event}},
+			Retry:      &retry,
+		}},
+	}
+}
+
+// ServiceQueue names the queue service consumes event from.
+func ServiceQueue(service, event string) string {
+	return fmt.Sprintf("%s.%s", service, event)
+}
+
+// DeclareTopology declares the exchanges and queues shared by every service.
+func DeclareTopology(d Declarer) error {
+	return SharedTopology.Apply(d)
+}
+
+// Merge returns t with the declarations of others appended.
+func (t Topology) Merge(others ...Topology) Topology {
+	merged := Topology{
+		Exchanges: append([]ExchangeSpec(nil), t.Exchanges...),
+		Queues:    append([]QueueSpec(nil), t.Queues...),
+	}
+	for _, o := range others {
+		merged.Exchanges = append(merged.Exchanges, o.Exchanges...)
+		merged.Queues = append(merged.Queues, o.Queues...)
+	}
+
+	return merged
+}
+
+// Validate checks t is consistent on its own. Bindings may name exchanges
+// outside t, such as those of SharedTopology.
+func (t Topology) Validate() error {
+	kinds := map[string]string{}
+	for _, e := range t.Exchanges {
+		if e.Name == "" {
+			return fmt.Errorf("%w: exchange without a name", ErrInvalidTopology)
+		}
+		if e.Kind != ExchangeDirect && e.Kind != ExchangeFanout {
+			return fmt.Errorf("%w: exchange %s has unsupported kind %q", ErrInvalidTopology, e.Name, e.Kind)
+		}
+		if kind, ok := kinds[e.Name]; ok && kind != e.Kind {
+			return fmt.Errorf("%w: exchange %s declared as both %s and %s", ErrInvalidTopology, e.Name, kind, e.Kind)
+		}
+		kinds[e.Name] = e.Kind
+	}
+
+	for _, q := range t.Queues {
+		if q.Name == "" {
+			return fmt.Errorf("%w: queue without a name", ErrInvalidTopology)
+		}
+		if q.DeadLetter {
+			if _, ok := q.Options.Args["x-dead-letter-exchange"]; ok {
+				return fmt.Errorf("%w: queue %s sets both DeadLetter and x-dead-letter-exchange", ErrInvalidTopology, q.Name)
+			}
+		}
+		for _, b := range q.Bindings {
+			if b.Exchange == "" {
+				return fmt.Errorf("%w: queue %s bound to the default exchange", ErrInvalidTopology, q.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Apply declares t on d: exchanges first, then queues with their retry
+// queues, then bindings.
+func (t Topology) Apply(d Declarer) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	for _, e := range t.Exchanges {
+		if err := d.DeclareExchange(e.Name, e.Kind); err != nil {
+			return fmt.Errorf("declaring exchange %s: %w", e.Name, err)
+		}
+	}
+
+	for _, q := range t.Queues {
+		if _, err := d.DeclareQueue(q.Name, q.options()); err != nil {
+			return fmt.Errorf("declaring queue %s: %w", q.Name, err)
+		}
+
+		if q.Retry != nil {
+			if err := declareRetryQueues(d, *q.Retry); err != nil {
+				return fmt.Errorf("declaring retry queues for %s: %w", q.Name, err)
+			}
+		}
+	}
+
+	for _, q := range t.Queues {
+		for _, b := range q.Bindings {
+			if err := d.BindQueue(q.Name, b.RoutingKey, b.Exchange); err != nil {
+				return fmt.Errorf("binding %s to %s: %w", q.Name, b.Exchange, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (q QueueSpec) options() QueueOptions {
+	opts := q.Options
+	if !q.DeadLetter {
+		return opts
+	}
+
+	args := make(map[string]interface{}, len(opts.Args)+1)
+	for k, v := range opts.Args {
+		args[k] = v
+	}
+	args["x-dead-letter-exchange"] = dlx
+	opts.Args = args
+
+	return opts
+}
@@ -0,0 +1,79 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/broker"
+	"github.com/juxue97/common/broker/inmem"
+)
+
+func TestTopologyApply(t *testing.T) {
+	ctx := context.Background()
+	b := inmem.NewBroker()
+
+	retry := broker.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second}
+	topology := broker.SharedTopology.Merge(broker.PaymentTopology(retry), broker.Topology{
+		Exchanges: []broker.ExchangeSpec{{Name: "audit", Kind: broker.ExchangeFanout}},
+		Queues: []broker.QueueSpec{{
+			Name:       "audit.paid",
+			Options:    broker.QueueOptions{Durable: true},
+			DeadLetter: true,
+			Bindings:   []broker.BindingSpec{{Exchange: broker.OrderPaidEvent}, {Exchange: "audit"}},
+		}},
+	})
+
+	// applying again, as every replica does at startup, must not fail
+	for i := 0; i < 2; i++ {
+		if err := topology.Apply(b); err != nil {
+			t.Fatalf("apply %d: %v", i+1, err)
+		}
+	}
+
+	for _, q := range []string{broker.OrderCreatedEvent, "dlq_main", "retry.1s", "retry.2s"} {
+		if err := b.Publish(ctx, "", q, broker.Message{}); err != nil {
+			t.Errorf("expected queue %s to be declared: %v", q, err)
+		}
+	}
+
+	if err := b.Publish(ctx, broker.OrderPaidEvent, "", broker.Message{Body: []byte("paid")}); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Ready("audit.paid"); got != 1 {
+		t.Fatalf("expected one message bound through order.paid, got %d", got)
+	}
+
+	before := b.Ready("dlq_main")
+	d, _, err := b.Get(ctx, "audit.paid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Nack(false)
+	if got := b.Ready("dlq_main"); got != before+1 {
+		t.Errorf("expected the rejected message dead-lettered to the dlq, got %d", got-before)
+	}
+}
+
+func TestTopologyValidate(t *testing.T) {
+	tests := map[string]broker.Topology{
+		"conflicting exchange kinds": {Exchanges: []broker.ExchangeSpec{
+			{Name: "x", Kind: broker.ExchangeDirect},
+			{Name: "x", Kind: broker.ExchangeFanout},
+		}},
+		"unsupported exchange kind": {Exchanges: []broker.ExchangeSpec{{Name: "x", Kind: "topic"}}},
+		"unnamed queue":             {Queues: []broker.QueueSpec{{}}},
+		"default exchange binding": {Queues: []broker.QueueSpec{
+			{Name: "q", Bindings: []broker.BindingSpec{{RoutingKey: "q"}}},
+		}},
+	}
+
+	for name, topology := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := topology.Apply(inmem.NewBroker()); !errors.Is(err, broker.ErrInvalidTopology) {
+				t.Errorf("expected ErrInvalidTopology, got %v", err)
+			}
+		})
+	}
+}
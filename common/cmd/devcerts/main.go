@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/juxue97/common/tlsconfig"
+)
+
+const usage = `usage: devcerts [flags] [services...]
+
+writes a development CA and a certificate per service, by default for
+gateway, orders, stocks and payments
+
+flags:
+`
+
+func main() {
+	dir := flag.String("dir", "certs", "directory to write certificates to")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	services := flag.Args()
+	if len(services) == 0 {
+		services = []string{"gateway", "orders", "stocks", "payments"}
+	}
+
+	if err := tlsconfig.GenerateDev(*dir, services...); err != nil {
+		log.Fatalf("failed to generate certificates: %v", err)
+	}
+
+	fmt.Printf("wrote certificates for %v to %s\n", services, *dir)
+}
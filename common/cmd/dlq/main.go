@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/juxue97/common"
+	"github.com/juxue97/common/broker"
+)
+
+var (
+	amqpUser = common.GetString("RABBITMQ_USER", "juxue")
+	amqpPass = common.GetString("RABBITMQ_PASS", "veryStrongPassword")
+	amqpHost = common.GetString("RABBITMQ_HOST", "localhost")
+	amqpPort = common.GetString("RABBITMQ_PORT", "5672")
+)
+
+const usage = `usage: dlq [flags] <command> [ids...]
+
+commands:
+  list            list dead letters
+  replay [ids]    replay dead letters to the queue that failed them
+  purge [ids]     drop dead letters
+
+flags:
+`
+
+func main() {
+	limit := flag.Int("limit", 0, "maximum number of messages to list, 0 for all, which republishes the whole queue")
+	all := flag.Bool("all", false, "replay or purge every message when no ids are given")
+	showBody := flag.Bool("body", false, "include message bodies when listing")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	amqpBroker, err := broker.Connect(ctx, amqpUser, amqpPass, amqpHost, amqpPort)
+	if err != nil {
+		log.Fatalf("failed to connect to rabbitmq: %v", err)
+	}
+	defer amqpBroker.Close()
+
+	dlq := broker.NewDLQ(amqpBroker)
+	command, ids := flag.Arg(0), flag.Args()[1:]
+
+	switch command {
+	case "list":
+		messages, err := dlq.List(ctx, *limit)
+		if err != nil {
+			log.Fatalf("failed to list dead letters: %v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tEXCHANGE\tROUTING KEY\tQUEUE\tRETRIES\tFAILED AT\tLAST ERROR")
+		for _, m := range messages {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", m.ID, m.Exchange, m.RoutingKey, m.Queue, m.RetryCount, m.FailedAt, m.LastError)
+			if *showBody {
+				fmt.Fprintf(w, "\t%s\n", m.Body)
+			}
+		}
+		w.Flush()
+
+	case "replay", "purge":
+		if len(ids) == 0 && !*all {
+			log.Fatalf("%s needs message ids or -all", command)
+		}
+
+		fn := dlq.Replay
+		if command == "purge" {
+			fn = dlq.Purge
+		}
+
+		count, err := fn(ctx, ids)
+		fmt.Printf("%s: %d message(s)\n", command, count)
+		if err != nil {
+			log.Fatalf("failed to %s some dead letters: %v", command, err)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
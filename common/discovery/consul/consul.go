@@ -5,15 +5,42 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	consul "github.com/hashicorp/consul/api"
+	"github.com/juxue97/common"
+	"github.com/juxue97/common/discovery"
 )
 
+const (
+	metaVersion  = "version"
+	metaZone     = "zone"
+	metaProtocol = "protocol"
+	metaWeight   = "weight"
+)
+
+const watchWait = 5 * time.Minute
+
+var watchBackoff = common.RetryConfig{
+	BaseDelay: 500 * time.Millisecond,
+	MaxDelay:  30 * time.Second,
+}
+
 type Registry struct {
 	client *consul.Client
+
+	mu sync.Mutex
+	// grpcCheckInterval is set when gRPC instances are checked by Consul
+	// instead of by TTL.
+	grpcCheckInterval   time.Duration
+	grpcCheckTLS        bool
+	grpcCheckSkipVerify bool
+	grpcChecked         map[string]bool
 }
 
 func NewRegistry(addr, serviceName string) (*Registry, error) {
@@ -25,11 +52,25 @@ func NewRegistry(addr, serviceName string) (*Registry, error) {
 		return nil, err
 	}
 
-	return &Registry{client}, nil
+	return &Registry{client: client, grpcChecked: map[string]bool{}}, nil
 }
 
-func (r *Registry) Register(ctx context.Context, instanceID, serviceName, hostPort string) error {
-	parts := strings.Split(hostPort, ":")
+// EnableGRPCHealthCheck makes Consul poll the grpc.health.v1 service of
+// instances registered with the "grpc" protocol every interval for their
+// readiness. Set useTLS when the instances serve TLS, and skipVerify when
+// their certificates aren't signed by a CA the Consul agent trusts. The
+// check presents no client certificate, so it fails against mutual TLS.
+func (r *Registry) EnableGRPCHealthCheck(interval time.Duration, useTLS, skipVerify bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.grpcCheckInterval = interval
+	r.grpcCheckTLS = useTLS
+	r.grpcCheckSkipVerify = skipVerify
+}
+
+func (r *Registry) Register(ctx context.Context, instance discovery.Instance) error {
+	parts := strings.Split(instance.Address, ":")
 	if len(parts) != 2 {
 		return errors.New("invalid host:port format. Eg: localhost:8081")
 	}
@@ -39,41 +80,184 @@ func (r *Registry) Register(ctx context.Context, instanceID, serviceName, hostPo
 		return err
 	}
 	host := parts[0]
+
+	weight := instance.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+
+	// liveness is the heartbeat; an instance that stops sending it is removed
+	alive := &consul.AgentServiceCheck{
+		CheckID:                        instance.ID,
+		Name:                           "alive",
+		TTL:                            discovery.DefaultTTL.String(),
+		DeregisterCriticalServiceAfter: discovery.DefaultDeregisterCriticalAfter.String(),
+	}
+	// readiness only keeps it out of Discover while a dependency is down
+	ready := &consul.AgentServiceCheck{
+		CheckID: readyCheckID(instance.ID),
+		Name:    "ready",
+		TTL:     discovery.DefaultTTL.String(),
+	}
+
+	r.mu.Lock()
+	grpcCheck := r.grpcCheckInterval > 0 && instance.Protocol == "grpc"
+	if grpcCheck {
+		ready.TTL = ""
+		ready.GRPC = instance.Address
+		ready.GRPCUseTLS = r.grpcCheckTLS
+		ready.TLSSkipVerify = r.grpcCheckSkipVerify
+		ready.Interval = r.grpcCheckInterval.String()
+		ready.Timeout = "1s"
+	}
+	r.grpcChecked[instance.ID] = grpcCheck
+	r.mu.Unlock()
+
 	return r.client.Agent().ServiceRegister(
 		&consul.AgentServiceRegistration{
-			ID:      instanceID,
-			Name:    serviceName,
+			ID:      instance.ID,
+			Name:    instance.ServiceName,
 			Port:    port,
 			Address: host,
-			Check: &consul.AgentServiceCheck{
-				CheckID:                        instanceID,
-				TLSSkipVerify:                  true,
-				TTL:                            "5s",
-				Timeout:                        "1s",
-				DeregisterCriticalServiceAfter: "10s",
+			Tags:    instance.Tags,
+			Meta: map[string]string{
+				metaVersion:  instance.Version,
+				metaZone:     instance.Zone,
+				metaProtocol: instance.Protocol,
+				metaWeight:   strconv.Itoa(weight),
 			},
+			Weights: &consul.AgentWeights{Passing: weight, Warning: 1},
+			Checks:  consul.AgentServiceChecks{alive, ready},
 		})
 }
 
 func (r *Registry) Deregister(ctx context.Context, instanceID, serviceName string) error {
 	log.Printf("Deregistering service %s", instanceID)
-	return r.client.Agent().CheckDeregister(instanceID)
+
+	r.mu.Lock()
+	delete(r.grpcChecked, instanceID)
+	r.mu.Unlock()
+
+	// removes the service with its checks; removing only a check would
+	// leave it registered and passing
+	return r.client.Agent().ServiceDeregisterOpts(instanceID, (&consul.QueryOptions{}).WithContext(ctx))
 }
 
-func (r *Registry) Discover(ctx context.Context, serviceName string) ([]string, error) {
-	entries, _, err := r.client.Health().Service(serviceName, "", true, nil)
+func (r *Registry) Discover(ctx context.Context, serviceName string) ([]discovery.Instance, error) {
+	entries, _, err := r.client.Health().Service(serviceName, "", true, (&consul.QueryOptions{}).WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
 
-	var instances []string
+	return instances(entries), nil
+}
+
+// Watch long-polls the health endpoint with blocking queries, so changes
+// arrive as soon as Consul sees them.
+func (r *Registry) Watch(ctx context.Context, serviceName string) (<-chan []discovery.Instance, error) {
+	out := make(chan []discovery.Instance)
+	go func() {
+		defer close(out)
+
+		var index uint64
+		for attempt := 1; ; {
+			opts := (&consul.QueryOptions{WaitIndex: index, WaitTime: watchWait}).WithContext(ctx)
+			entries, meta, err := r.client.Health().Service(serviceName, "", true, opts)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf("failed to watch service %s: %v", serviceName, err)
+				select {
+				case <-time.After(watchBackoff.Backoff(attempt)):
+				case <-ctx.Done():
+					return
+				}
+				attempt++
+				continue
+			}
+			attempt = 1
+
+			if meta.LastIndex == index {
+				continue
+			}
+			// the index only moves forward unless Consul lost its state
+			if meta.LastIndex < index {
+				index = 0
+				continue
+			}
+			index = meta.LastIndex
+
+			select {
+			case out <- instances(entries):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func instances(entries []*consul.ServiceEntry) []discovery.Instance {
+	res := make([]discovery.Instance, 0, len(entries))
 	for _, entry := range entries {
-		instances = append(instances, fmt.Sprintf("%s:%d", entry.Service.Address, entry.Service.Port))
+		s := entry.Service
+		weight, err := strconv.Atoi(s.Meta[metaWeight])
+		if err != nil {
+			weight = s.Weights.Passing
+		}
+
+		res = append(res, discovery.Instance{
+			ID:          s.ID,
+			ServiceName: s.Service,
+			Address:     fmt.Sprintf("%s:%d", s.Address, s.Port),
+			Version:     s.Meta[metaVersion],
+			Zone:        s.Meta[metaZone],
+			Protocol:    s.Meta[metaProtocol],
+			Weight:      weight,
+			Tags:        s.Tags,
+		})
 	}
 
-	return instances, nil
+	return res
 }
 
 func (r *Registry) HealthCheck(instanceID, serviceName string) error {
-	return r.client.Agent().UpdateTTL(instanceID, "online", api.HealthPassing)
+	return updateTTL(r.client, instanceID, api.HealthPassing)
+}
+
+func (r *Registry) SetReady(instanceID, serviceName string, ready bool) error {
+	r.mu.Lock()
+	grpcCheck := r.grpcChecked[instanceID]
+	r.mu.Unlock()
+	// Consul polls the gRPC health service, which reports readiness itself
+	if grpcCheck {
+		return nil
+	}
+
+	status := api.HealthPassing
+	if !ready {
+		status = api.HealthCritical
+	}
+
+	return updateTTL(r.client, readyCheckID(instanceID), status)
+}
+
+func readyCheckID(instanceID string) string {
+	return instanceID + ":ready"
+}
+
+// updateTTL maps the errors for an unknown check to discovery.ErrNotRegistered.
+// Consul answers 404 since 1.11 and 500 naming the check before that.
+func updateTTL(client *consul.Client, checkID, status string) error {
+	err := client.Agent().UpdateTTL(checkID, status, status)
+	var statusErr consul.StatusError
+	if errors.As(err, &statusErr) && (statusErr.Code == http.StatusNotFound ||
+		strings.Contains(statusErr.Body, "Unknown check") ||
+		strings.Contains(statusErr.Body, "does not have associated TTL")) {
+		return fmt.Errorf("%w: %s", discovery.ErrNotRegistered, statusErr.Body)
+	}
+
+	return err
 }
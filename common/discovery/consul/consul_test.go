@@ -0,0 +1,91 @@
+package consul_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/discovery/consul"
+)
+
+// fakeAgent records service registrations and knows no checks.
+type fakeAgent struct {
+	mu         sync.Mutex
+	registered []api.AgentServiceRegistration
+}
+
+func (a *fakeAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v1/agent/service/register":
+		var reg api.AgentServiceRegistration
+		if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.mu.Lock()
+		a.registered = append(a.registered, reg)
+		a.mu.Unlock()
+	case strings.HasPrefix(r.URL.Path, "/v1/agent/check/update/"):
+		http.Error(w, "Unknown check ID", http.StatusNotFound)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newRegistry(t *testing.T) (*consul.Registry, *fakeAgent) {
+	t.Helper()
+
+	agent := &fakeAgent{}
+	srv := httptest.NewServer(agent)
+	t.Cleanup(srv.Close)
+
+	registry, err := consul.NewRegistry(strings.TrimPrefix(srv.URL, "http://"), "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry, agent
+}
+
+func TestRegisterSeparatesLivenessFromReadiness(t *testing.T) {
+	registry, agent := newRegistry(t)
+	registry.EnableGRPCHealthCheck(time.Second, true, false)
+
+	instance := discovery.Instance{ID: "orders-1", ServiceName: "orders", Address: "10.0.0.1:8001", Protocol: "grpc"}
+	if err := registry.Register(context.Background(), instance); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(agent.registered) != 1 || len(agent.registered[0].Checks) != 2 {
+		t.Fatalf("expected one registration with two checks, got %+v", agent.registered)
+	}
+	alive, ready := agent.registered[0].Checks[0], agent.registered[0].Checks[1]
+
+	if alive.TTL == "" || alive.DeregisterCriticalServiceAfter == "" {
+		t.Errorf("expected a TTL liveness check that deregisters, got %+v", alive)
+	}
+	if ready.GRPC != instance.Address || !ready.GRPCUseTLS || ready.DeregisterCriticalServiceAfter != "" {
+		t.Errorf("expected a gRPC readiness check that never deregisters, got %+v", ready)
+	}
+	if ready.TLSSkipVerify {
+		t.Error("expected the gRPC check to verify certificates when a CA is configured")
+	}
+}
+
+func TestHealthCheckReportsUnknownChecks(t *testing.T) {
+	registry, _ := newRegistry(t)
+
+	if err := registry.HealthCheck("orders-1", "orders"); !errors.Is(err, discovery.ErrNotRegistered) {
+		t.Errorf("expected ErrNotRegistered from HealthCheck, got %v", err)
+	}
+	if err := registry.SetReady("orders-1", "orders", true); !errors.Is(err, discovery.ErrNotRegistered) {
+		t.Errorf("expected ErrNotRegistered from SetReady, got %v", err)
+	}
+}
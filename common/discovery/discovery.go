@@ -2,18 +2,173 @@ package discovery
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/juxue97/common"
+)
+
+const defaultWeight = 1
+
+const (
+	// DefaultTTL is how long an instance stays healthy after its last
+	// HealthCheck.
+	DefaultTTL = 5 * time.Second
+	// DefaultDeregisterCriticalAfter is how long an instance may stay
+	// critical before the registry removes it.
+	DefaultDeregisterCriticalAfter = 10 * time.Second
 )
 
+// Instance is a registered instance of a service. Weight is advisory, for
+// balancers that support it.
+type Instance struct {
+	ID          string
+	ServiceName string
+	Address     string
+	Version     string
+	Zone        string
+	Protocol    string
+	Weight      int
+	Tags        []string
+}
+
+// ErrNotRegistered is returned by HealthCheck and SetReady when the registry
+// no longer knows the instance, e.g. after removing it as critical, so the
+// caller should register it again.
+var ErrNotRegistered = errors.New("discovery: instance is not registered")
+
 type Registry interface {
-	Register(ctx context.Context, instanceID, serviceName, hostPort string) error
+	Register(ctx context.Context, instance Instance) error
 	Deregister(ctx context.Context, instanceID, serviceName string) error
-	Discover(ctx context.Context, serviceName string) ([]string, error)
+	// Discover returns the instances of serviceName that are alive and ready.
+	Discover(ctx context.Context, serviceName string) ([]Instance, error)
+	// Watch sends the instances of serviceName now and again whenever they
+	// change, until ctx is done and the channel is closed.
+	Watch(ctx context.Context, serviceName string) (<-chan []Instance, error)
+	// HealthCheck reports the instance alive. An instance that stops
+	// reporting is removed once it has been critical for a while.
 	HealthCheck(instanceID, serviceName string) error
+	// SetReady reports whether the instance can take traffic. An unready
+	// instance stays registered but is left out of Discover and Watch.
+	SetReady(instanceID, serviceName string, ready bool) error
 }
 
 func GenerateInstanceID(serviceName string) string {
 	return fmt.Sprintf("%s-%d", serviceName, rand.New(rand.NewSource(time.Now().UnixNano())).Int())
 }
+
+// NewInstance describes this process as an instance of serviceName, taking
+// its metadata from SERVICE_VERSION, SERVICE_ZONE, SERVICE_WEIGHT and the
+// comma separated SERVICE_TAGS.
+func NewInstance(serviceName, address, protocol string) Instance {
+	return Instance{
+		ID:          GenerateInstanceID(serviceName),
+		ServiceName: serviceName,
+		Address:     address,
+		Version:     common.GetString("SERVICE_VERSION", ""),
+		Zone:        common.GetString("SERVICE_ZONE", ""),
+		Protocol:    protocol,
+		Weight:      common.GetInt("SERVICE_WEIGHT", defaultWeight),
+		Tags:        splitTags(common.GetString("SERVICE_TAGS", "")),
+	}
+}
+
+// Selector picks instances by their metadata. Empty fields match any
+// instance, and an instance must carry every tag listed.
+type Selector struct {
+	Version string
+	// ExcludeVersion leaves out the instances of a version, such as a canary
+	// the stable pool must not include.
+	ExcludeVersion string
+	Zone           string
+	Protocol       string
+	Tags           []string
+}
+
+func (s Selector) Matches(i Instance) bool {
+	if s.Version != "" && s.Version != i.Version {
+		return false
+	}
+	if s.ExcludeVersion != "" && s.ExcludeVersion == i.Version {
+		return false
+	}
+	if s.Zone != "" && s.Zone != i.Zone {
+		return false
+	}
+	if s.Protocol != "" && s.Protocol != i.Protocol {
+		return false
+	}
+	for _, tag := range s.Tags {
+		if !slices.Contains(i.Tags, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s Selector) Filter(instances []Instance) []Instance {
+	var res []Instance
+	for _, i := range instances {
+		if s.Matches(i) {
+			res = append(res, i)
+		}
+	}
+
+	return res
+}
+
+// Target returns the resolver target for the instances of serviceName that
+// s matches, e.g. registry:///orders?version=v2.
+func (s Selector) Target(serviceName string) string {
+	q := url.Values{}
+	if s.Version != "" {
+		q.Set("version", s.Version)
+	}
+	if s.ExcludeVersion != "" {
+		q.Set("exclude_version", s.ExcludeVersion)
+	}
+	if s.Zone != "" {
+		q.Set("zone", s.Zone)
+	}
+	if s.Protocol != "" {
+		q.Set("protocol", s.Protocol)
+	}
+	for _, tag := range s.Tags {
+		q.Add("tag", tag)
+	}
+
+	target := Scheme + ":///" + serviceName
+	if len(q) > 0 {
+		target += "?" + q.Encode()
+	}
+
+	return target
+}
+
+// ParseSelector reads a selector from the query of a target.
+func ParseSelector(q url.Values) Selector {
+	return Selector{
+		Version:        q.Get("version"),
+		ExcludeVersion: q.Get("exclude_version"),
+		Zone:           q.Get("zone"),
+		Protocol:       q.Get("protocol"),
+		Tags:           q["tag"],
+	}
+}
+
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
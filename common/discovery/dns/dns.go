@@ -0,0 +1,136 @@
+// Package dns discovers instances from DNS SRV records, as published by
+// Kubernetes headless services or a DNS server in front of Consul.
+// Registration is left to whatever manages the records, so Register,
+// Deregister, HealthCheck and SetReady succeed without effect.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juxue97/common/discovery"
+)
+
+const (
+	// DefaultFormat names the SRV record of a service, e.g. _grpc._tcp.orders.
+	DefaultFormat  = "_grpc._tcp.%s"
+	defaultRefresh = 5 * time.Second
+)
+
+// resolver is implemented by *net.Resolver.
+type resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+type Registry struct {
+	format   string
+	refresh  time.Duration
+	resolver resolver
+}
+
+// NewRegistry looks up the SRV record named by format with the service name
+// substituted for %s, polling it every refresh interval for Watch.
+func NewRegistry(format string, refresh time.Duration) *Registry {
+	if format == "" {
+		format = DefaultFormat
+	}
+	if refresh <= 0 {
+		refresh = defaultRefresh
+	}
+
+	return &Registry{
+		format:   format,
+		refresh:  refresh,
+		resolver: net.DefaultResolver,
+	}
+}
+
+func (r *Registry) Register(ctx context.Context, instance discovery.Instance) error {
+	return nil
+}
+
+func (r *Registry) Deregister(ctx context.Context, instanceID, serviceName string) error {
+	return nil
+}
+
+func (r *Registry) HealthCheck(instanceID, serviceName string) error {
+	return nil
+}
+
+func (r *Registry) SetReady(instanceID, serviceName string, ready bool) error {
+	return nil
+}
+
+func (r *Registry) Discover(ctx context.Context, serviceName string) ([]discovery.Instance, error) {
+	name := r.name(serviceName)
+	_, records, err := r.resolver.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no SRV records for %s", name)
+	}
+
+	res := make([]discovery.Instance, 0, len(records))
+	for _, srv := range records {
+		addr := net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), fmt.Sprint(srv.Port))
+		res = append(res, discovery.Instance{
+			ID:          addr,
+			ServiceName: serviceName,
+			Address:     addr,
+			Weight:      int(srv.Weight),
+		})
+	}
+	// resolvers shuffle records of equal priority, which is no change
+	sort.Slice(res, func(i, j int) bool { return res[i].Address < res[j].Address })
+
+	return res, nil
+}
+
+// Watch polls the SRV record and sends the instances whenever they change.
+// Lookup failures are logged and the last answer is kept.
+func (r *Registry) Watch(ctx context.Context, serviceName string) (<-chan []discovery.Instance, error) {
+	out := make(chan []discovery.Instance)
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(r.refresh)
+		defer ticker.Stop()
+
+		var last []discovery.Instance
+		for first := true; ; first = false {
+			instances, err := r.Discover(ctx, serviceName)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf("failed to look up service %s: %v", serviceName, err)
+			} else if first || !reflect.DeepEqual(instances, last) {
+				last = instances
+				select {
+				case out <- instances:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (r *Registry) name(serviceName string) string {
+	return fmt.Sprintf(r.format, serviceName)
+}
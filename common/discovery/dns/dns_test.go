@@ -0,0 +1,114 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/discovery"
+)
+
+// stubResolver answers with its records, reversed on every other lookup as
+// resolvers shuffle them.
+type stubResolver struct {
+	mu      sync.Mutex
+	records []*net.SRV
+	err     error
+	lookups int
+}
+
+func (s *stubResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lookups++
+	if s.err != nil {
+		return "", nil, s.err
+	}
+
+	records := append([]*net.SRV(nil), s.records...)
+	if s.lookups%2 == 0 {
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+	}
+	return name, records, nil
+}
+
+func (s *stubResolver) set(records []*net.SRV, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records, s.err = records, err
+}
+
+func TestDiscover(t *testing.T) {
+	stub := &stubResolver{records: []*net.SRV{
+		{Target: "orders-1.orders.default.svc.", Port: 8001, Weight: 10},
+		{Target: "orders-0.orders.default.svc.", Port: 8001, Weight: 5},
+	}}
+	r := NewRegistry("", 0)
+	r.resolver = stub
+
+	instances, err := r.Discover(context.Background(), "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []discovery.Instance{
+		{ID: "orders-0.orders.default.svc:8001", ServiceName: "orders", Address: "orders-0.orders.default.svc:8001", Weight: 5},
+		{ID: "orders-1.orders.default.svc:8001", ServiceName: "orders", Address: "orders-1.orders.default.svc:8001", Weight: 10},
+	}
+	if len(instances) != len(want) {
+		t.Fatalf("expected %v, got %v", want, instances)
+	}
+	for i := range want {
+		if instances[i].ID != want[i].ID || instances[i].Address != want[i].Address || instances[i].Weight != want[i].Weight {
+			t.Errorf("instance %d: expected %v, got %v", i, want[i], instances[i])
+		}
+	}
+
+	stub.set(nil, nil)
+	if _, err := r.Discover(context.Background(), "orders"); err == nil {
+		t.Error("expected an error without records")
+	}
+}
+
+func TestWatchIgnoresShuffledRecords(t *testing.T) {
+	stub := &stubResolver{records: []*net.SRV{
+		{Target: "stocks-0.", Port: 8003},
+		{Target: "stocks-1.", Port: 8003},
+	}}
+	r := NewRegistry("", 5*time.Millisecond)
+	r.resolver = stub
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	updates, err := r.Watch(ctx, "stocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-updates; len(got) != 2 {
+		t.Fatalf("expected both instances, got %v", got)
+	}
+
+	// a few polls answered in a different order are no change
+	select {
+	case got := <-updates:
+		t.Fatalf("expected no update for reordered records, got %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// a failed lookup keeps the last answer
+	stub.set(nil, errors.New("no such host"))
+	time.Sleep(20 * time.Millisecond)
+
+	stub.set([]*net.SRV{{Target: "stocks-0.", Port: 8003}}, nil)
+	if got := <-updates; len(got) != 1 || got[0].Address != "stocks-0:8003" {
+		t.Fatalf("expected only stocks-0, got %v", got)
+	}
+}
@@ -0,0 +1,262 @@
+// Package file is a static registry read from a YAML file, for running the
+// services without Consul. The file maps each service to its instances,
+// either as bare addresses or with metadata:
+//
+//	orders:
+//	  - localhost:8001
+//	stocks:
+//	  - address: localhost:8003
+//	    version: v2
+//	    tags: [canary]
+//
+// The file is re-read when it changes. Register, Deregister, HealthCheck and
+// SetReady succeed without effect since the file is the source of truth.
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/juxue97/common/discovery"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultReload = time.Second
+
+type Registry struct {
+	path   string
+	reload time.Duration
+
+	mu       sync.RWMutex
+	services map[string][]discovery.Instance
+	modTime  time.Time
+	watchers map[chan struct{}]struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type entry struct {
+	ID       string   `yaml:"id"`
+	Address  string   `yaml:"address"`
+	Version  string   `yaml:"version"`
+	Zone     string   `yaml:"zone"`
+	Protocol string   `yaml:"protocol"`
+	Weight   int      `yaml:"weight"`
+	Tags     []string `yaml:"tags"`
+}
+
+func (e *entry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&e.Address)
+	}
+
+	type plain entry
+	return node.Decode((*plain)(e))
+}
+
+// NewRegistry loads path and reloads it every reload interval when its
+// modification time changes.
+func NewRegistry(path string, reload time.Duration) (*Registry, error) {
+	if reload <= 0 {
+		reload = defaultReload
+	}
+
+	r := &Registry{
+		path:     path,
+		reload:   reload,
+		watchers: map[chan struct{}]struct{}{},
+		done:     make(chan struct{}),
+	}
+
+	if _, err := r.load(); err != nil {
+		return nil, err
+	}
+
+	go r.watchFile()
+
+	return r, nil
+}
+
+func (r *Registry) Register(ctx context.Context, instance discovery.Instance) error {
+	return nil
+}
+
+func (r *Registry) Deregister(ctx context.Context, instanceID, serviceName string) error {
+	return nil
+}
+
+func (r *Registry) HealthCheck(instanceID, serviceName string) error {
+	return nil
+}
+
+func (r *Registry) SetReady(instanceID, serviceName string, ready bool) error {
+	return nil
+}
+
+func (r *Registry) Discover(ctx context.Context, serviceName string) ([]discovery.Instance, error) {
+	instances := r.instances(serviceName)
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no address configured for %s in %s", serviceName, r.path)
+	}
+
+	return instances, nil
+}
+
+func (r *Registry) Watch(ctx context.Context, serviceName string) (<-chan []discovery.Instance, error) {
+	changed := make(chan struct{}, 1)
+	changed <- struct{}{}
+
+	r.mu.Lock()
+	r.watchers[changed] = struct{}{}
+	r.mu.Unlock()
+
+	out := make(chan []discovery.Instance)
+	go func() {
+		defer close(out)
+		defer func() {
+			r.mu.Lock()
+			delete(r.watchers, changed)
+			r.mu.Unlock()
+		}()
+
+		var last []discovery.Instance
+		for first := true; ; first = false {
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+
+			// a reload touching other services is not a change for this one
+			instances := r.instances(serviceName)
+			if !first && reflect.DeepEqual(instances, last) {
+				continue
+			}
+			last = instances
+
+			select {
+			case out <- instances:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Close stops watching the file.
+func (r *Registry) Close() error {
+	r.closeOnce.Do(func() { close(r.done) })
+	return nil
+}
+
+func (r *Registry) instances(serviceName string) []discovery.Instance {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]discovery.Instance(nil), r.services[serviceName]...)
+}
+
+func (r *Registry) watchFile() {
+	ticker := time.NewTicker(r.reload)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := r.load()
+		if err != nil {
+			// keep serving the last good file
+			log.Printf("failed to reload registry file %s: %v", r.path, err)
+			continue
+		}
+		if changed {
+			log.Printf("reloaded registry file %s", r.path)
+		}
+	}
+}
+
+// load reads the file if it changed since the last load.
+func (r *Registry) load() (bool, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return false, err
+	}
+
+	services, err := parse(data)
+	if err != nil {
+		return false, fmt.Errorf("parsing %s: %w", r.path, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.services = services
+	r.modTime = info.ModTime()
+	for changed := range r.watchers {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	return true, nil
+}
+
+func parse(data []byte) (map[string][]discovery.Instance, error) {
+	var raw map[string][]entry
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	services := make(map[string][]discovery.Instance, len(raw))
+	for serviceName, entries := range raw {
+		for i, e := range entries {
+			if e.Address == "" {
+				return nil, fmt.Errorf("%s instance %d: %w", serviceName, i, errors.New("address is required"))
+			}
+
+			id := e.ID
+			if id == "" {
+				id = fmt.Sprintf("%s-%s", serviceName, e.Address)
+			}
+
+			services[serviceName] = append(services[serviceName], discovery.Instance{
+				ID:          id,
+				ServiceName: serviceName,
+				Address:     e.Address,
+				Version:     e.Version,
+				Zone:        e.Zone,
+				Protocol:    e.Protocol,
+				Weight:      e.Weight,
+				Tags:        e.Tags,
+			})
+		}
+	}
+
+	return services, nil
+}
@@ -0,0 +1,96 @@
+package file_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/discovery/file"
+)
+
+func TestRegistryReloadsOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	write(t, path, `
+orders:
+  - localhost:8001
+  - address: localhost:8101
+    version: v2
+    tags: [canary]
+stocks:
+  - localhost:8003
+`)
+
+	registry, err := file.NewRegistry(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer registry.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	instances, err := registry.Discover(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("expected 2 orders instances, got %v", instances)
+	}
+	canary := discovery.Selector{Version: "v2", Tags: []string{"canary"}}.Filter(instances)
+	if len(canary) != 1 || canary[0].Address != "localhost:8101" {
+		t.Fatalf("expected the v2 canary, got %v", canary)
+	}
+
+	updates, err := registry.Watch(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-updates; len(got) != 2 {
+		t.Fatalf("expected the current instances first, got %v", got)
+	}
+
+	// keep the modification time from matching on coarse filesystems
+	later := time.Now().Add(time.Second)
+	write(t, path, `
+orders:
+  - localhost:8001
+`)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-updates:
+		if len(got) != 1 || got[0].Address != "localhost:8001" {
+			t.Fatalf("expected the reloaded instance, got %v", got)
+		}
+	case <-ctx.Done():
+		t.Fatal("no update after the file changed")
+	}
+
+	if _, err := registry.Discover(ctx, "stocks"); err == nil {
+		t.Error("expected stocks to be gone after the reload")
+	}
+}
+
+func TestRegistryRejectsMissingAddress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	write(t, path, `
+orders:
+  - version: v2
+`)
+
+	if _, err := file.NewRegistry(path, 0); err == nil {
+		t.Fatal("expected an error for an instance without an address")
+	}
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
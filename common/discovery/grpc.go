@@ -1,24 +1,31 @@
 package discovery
 
 import (
-	"context"
-	"math/rand"
-
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
-func ServiceConnection(ctx context.Context, serviceName string, registry Registry) (*grpc.ClientConn, error) {
-	addrs, err := registry.Discover(ctx, serviceName)
-	if err != nil {
-		return nil, err
-	}
+const roundRobinConfig = `{"loadBalancingConfig": [{"round_robin": {}}]}`
+
+// NewClient returns a connection to every healthy instance of serviceName,
+// balancing calls round-robin and following the registry as instances come
+// and go. It is meant to be created once and shared; callers must Close it.
+// Connections are plaintext unless opts carry transport credentials.
+func NewClient(serviceName string, registry Registry, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return NewSelectorClient(serviceName, Selector{}, registry, opts...)
+}
 
-	return grpc.NewClient(
-		addrs[rand.Intn(len(addrs))],
+// NewSelectorClient is NewClient restricted to the instances selector
+// matches, such as those of a canary version.
+func NewSelectorClient(serviceName string, selector Selector, registry Registry, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithResolvers(NewResolverBuilder(registry)),
+		grpc.WithDefaultServiceConfig(roundRobinConfig),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		// add middleware
 		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
-	)
+	}, opts...)
+
+	return grpc.NewClient(selector.Target(serviceName), opts...)
 }
@@ -1,35 +1,87 @@
+// Package inmem is an in-process registry for tests and local runs. Like a
+// Consul TTL check, an instance is healthy while HealthCheck is called within
+// its TTL, turns critical when it lapses and is removed once it has been
+// critical for DeregisterCriticalAfter. An instance reported unready is left
+// out of Discover and Watch but never removed for it.
 package inmem
 
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
+
+	"github.com/juxue97/common/discovery"
 )
 
+type Config struct {
+	TTL                     time.Duration
+	DeregisterCriticalAfter time.Duration
+}
+
 type Registry struct {
 	sync.RWMutex
-	addrs map[string]map[string]*serviceInstance
+	config   Config
+	addrs    map[string]map[string]*serviceInstance
+	watchers map[string]map[chan struct{}]struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 type serviceInstance struct {
-	hostPort   string
+	discovery.Instance
 	lastActive time.Time
+	critical   bool
+	unready    bool
 }
 
+// NewRegistry returns a registry using the Consul check defaults.
 func NewRegistry() *Registry {
-	return &Registry{addrs: map[string]map[string]*serviceInstance{}}
+	return NewRegistryWithConfig(Config{})
+}
+
+// NewRegistryWithConfig returns a registry that checks its instances in the
+// background until Close is called.
+func NewRegistryWithConfig(config Config) *Registry {
+	if config.TTL <= 0 {
+		config.TTL = discovery.DefaultTTL
+	}
+	if config.DeregisterCriticalAfter <= 0 {
+		config.DeregisterCriticalAfter = discovery.DefaultDeregisterCriticalAfter
+	}
+
+	r := &Registry{
+		config:   config,
+		addrs:    map[string]map[string]*serviceInstance{},
+		watchers: map[string]map[chan struct{}]struct{}{},
+		done:     make(chan struct{}),
+	}
+
+	go r.sweep()
+
+	return r
+}
+
+// Close stops the background checks.
+func (r *Registry) Close() error {
+	r.closeOnce.Do(func() { close(r.done) })
+	return nil
 }
 
-func (r *Registry) Register(ctx context.Context, instanceID, serviceName, hostPort string) error {
+func (r *Registry) Register(ctx context.Context, instance discovery.Instance) error {
 	r.Lock()
 	defer r.Unlock()
 
+	serviceName := instance.ServiceName
 	if _, ok := r.addrs[serviceName]; !ok {
 		r.addrs[serviceName] = map[string]*serviceInstance{}
 	}
 
-	r.addrs[serviceName][instanceID] = &serviceInstance{hostPort: hostPort, lastActive: time.Now()}
+	instance.Tags = append([]string(nil), instance.Tags...)
+	r.addrs[serviceName][instance.ID] = &serviceInstance{Instance: instance, lastActive: time.Now()}
+	r.notify(serviceName)
 
 	return nil
 }
@@ -42,7 +94,10 @@ func (r *Registry) Deregister(ctx context.Context, instanceID, serviceName strin
 		return nil
 	}
 
-	delete(r.addrs[serviceName], instanceID)
+	if _, ok := r.addrs[serviceName][instanceID]; ok {
+		delete(r.addrs[serviceName], instanceID)
+		r.notify(serviceName)
+	}
 
 	return nil
 }
@@ -51,50 +106,157 @@ func (r *Registry) HealthCheck(instanceID, serviceName string) error {
 	r.Lock()
 	defer r.Unlock()
 
-	if _, ok := r.addrs[serviceName]; !ok {
-		return errors.New("service is not registered yet")
+	i, err := r.instance(instanceID, serviceName)
+	if err != nil {
+		return err
 	}
 
-	if _, ok := r.addrs[serviceName][instanceID]; !ok {
-		return errors.New("service instance is not registered yet")
+	i.lastActive = time.Now()
+	if i.critical {
+		i.critical = false
+		r.notify(serviceName)
 	}
 
-	r.addrs[serviceName][instanceID].lastActive = time.Now()
+	return nil
+}
+
+func (r *Registry) SetReady(instanceID, serviceName string, ready bool) error {
+	r.Lock()
+	defer r.Unlock()
+
+	i, err := r.instance(instanceID, serviceName)
+	if err != nil {
+		return err
+	}
+
+	if i.unready == ready {
+		i.unready = !ready
+		r.notify(serviceName)
+	}
 
 	return nil
 }
 
-func (r *Registry) Discover(ctx context.Context, serviceName string) ([]string, error) {
-	r.RLock()
-	defer r.RUnlock()
+// instance must be called with the lock held.
+func (r *Registry) instance(instanceID, serviceName string) (*serviceInstance, error) {
+	i, ok := r.addrs[serviceName][instanceID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s of %s", discovery.ErrNotRegistered, instanceID, serviceName)
+	}
+
+	return i, nil
+}
 
-	if len(r.addrs[serviceName]) == 0 {
-		return nil, errors.New("no service address found")
+func (r *Registry) Discover(ctx context.Context, serviceName string) ([]discovery.Instance, error) {
+	instances := r.instances(serviceName)
+	if len(instances) == 0 {
+		return nil, errors.New("no healthy service instance found")
 	}
 
-	var res []string
-	for _, i := range r.addrs[serviceName] {
-		res = append(res, i.hostPort)
+	return instances, nil
+}
+
+func (r *Registry) Watch(ctx context.Context, serviceName string) (<-chan []discovery.Instance, error) {
+	changed := make(chan struct{}, 1)
+	changed <- struct{}{}
+
+	r.Lock()
+	if _, ok := r.watchers[serviceName]; !ok {
+		r.watchers[serviceName] = map[chan struct{}]struct{}{}
 	}
+	r.watchers[serviceName][changed] = struct{}{}
+	r.Unlock()
+
+	out := make(chan []discovery.Instance)
+	go func() {
+		defer close(out)
+		defer func() {
+			r.Lock()
+			delete(r.watchers[serviceName], changed)
+			r.Unlock()
+		}()
+
+		for {
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
 
-	return res, nil
+			// changes made while the watcher is busy collapse into one update
+			select {
+			case out <- r.instances(serviceName):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
 }
 
-func (r *Registry) ServiceAddresses(ctx context.Context, serviceName string) ([]string, error) {
+func (r *Registry) instances(serviceName string) []discovery.Instance {
 	r.RLock()
 	defer r.RUnlock()
 
-	if len(r.addrs[serviceName]) == 0 {
-		return nil, errors.New("no service address found")
-	}
-
-	var res []string
+	res := make([]discovery.Instance, 0, len(r.addrs[serviceName]))
 	for _, i := range r.addrs[serviceName] {
-		if i.lastActive.Before(time.Now().Add(-5 * time.Second)) {
+		if i.critical || i.unready {
 			continue
 		}
-		res = append(res, i.hostPort)
+		instance := i.Instance
+		instance.Tags = append([]string(nil), i.Tags...)
+		res = append(res, instance)
+	}
+
+	return res
+}
+
+func (r *Registry) sweep() {
+	// check often enough that an instance turns critical close to its TTL
+	ticker := time.NewTicker(r.config.TTL / 5)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case now := <-ticker.C:
+			r.check(now)
+		}
 	}
+}
 
-	return res, nil
+func (r *Registry) check(now time.Time) {
+	r.Lock()
+	defer r.Unlock()
+
+	for serviceName, instances := range r.addrs {
+		changed := false
+		for id, i := range instances {
+			silent := now.Sub(i.lastActive)
+			switch {
+			case silent > r.config.TTL+r.config.DeregisterCriticalAfter:
+				delete(instances, id)
+				changed = true
+			case silent > r.config.TTL && !i.critical:
+				i.critical = true
+				changed = true
+			}
+		}
+
+		if changed {
+			r.notify(serviceName)
+		}
+	}
+}
+
+// notify must be called with the lock held.
+func (r *Registry) notify(serviceName string) {
+	for changed := range r.watchers[serviceName] {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
 }
@@ -0,0 +1,134 @@
+package inmem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/discovery/inmem"
+)
+
+func TestRegistryEvictsStaleInstances(t *testing.T) {
+	registry := inmem.NewRegistryWithConfig(inmem.Config{
+		TTL:                     50 * time.Millisecond,
+		DeregisterCriticalAfter: 100 * time.Millisecond,
+	})
+	defer registry.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, id := range []string{"orders-1", "orders-2"} {
+		if err := registry.Register(ctx, discovery.Instance{ID: id, ServiceName: "orders", Address: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	updates, err := registry.Watch(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-updates; len(got) != 2 {
+		t.Fatalf("expected both instances, got %v", got)
+	}
+
+	heartbeat := func(id string) {
+		if err := registry.HealthCheck(id, "orders"); err != nil {
+			t.Errorf("health check %s: %v", id, err)
+		}
+	}
+
+	// only orders-1 heartbeats, so orders-2 turns critical
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		var got []discovery.Instance
+		select {
+		case got = <-updates:
+		case <-ticker.C:
+			heartbeat("orders-1")
+			continue
+		case <-ctx.Done():
+			t.Fatal("orders-2 never turned critical")
+		}
+		if len(got) == 1 && got[0].ID == "orders-1" {
+			break
+		}
+	}
+
+	instances, err := registry.Discover(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 1 || instances[0].ID != "orders-1" {
+		t.Fatalf("expected only the healthy instance, got %v", instances)
+	}
+
+	// a critical instance that heartbeats again is healthy again
+	heartbeat("orders-2")
+	if instances, _ := registry.Discover(ctx, "orders"); len(instances) != 2 {
+		t.Fatalf("expected orders-2 back, got %v", instances)
+	}
+
+	// once nothing heartbeats both turn critical and are then deregistered
+	for {
+		if _, err := registry.Discover(ctx, "orders"); err != nil {
+			break
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			t.Fatal("instances never turned critical")
+		}
+	}
+	time.Sleep(300 * time.Millisecond)
+	for _, id := range []string{"orders-1", "orders-2"} {
+		if err := registry.HealthCheck(id, "orders"); !errors.Is(err, discovery.ErrNotRegistered) {
+			t.Errorf("expected %s to be deregistered, got %v", id, err)
+		}
+	}
+}
+
+func TestRegistryHidesUnreadyInstances(t *testing.T) {
+	registry := inmem.NewRegistry()
+	defer registry.Close()
+
+	ctx := context.Background()
+	for _, id := range []string{"orders-1", "orders-2"} {
+		if err := registry.Register(ctx, discovery.Instance{ID: id, ServiceName: "orders", Address: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := registry.SetReady("orders-2", "orders", false); err != nil {
+		t.Fatal(err)
+	}
+	instances, err := registry.Discover(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 1 || instances[0].ID != "orders-1" {
+		t.Fatalf("expected only the ready instance, got %v", instances)
+	}
+
+	// unready is not unhealthy, so heartbeats don't bring it back
+	if err := registry.HealthCheck("orders-2", "orders"); err != nil {
+		t.Fatal(err)
+	}
+	if instances, _ := registry.Discover(ctx, "orders"); len(instances) != 1 {
+		t.Fatalf("expected orders-2 to stay hidden, got %v", instances)
+	}
+
+	if err := registry.SetReady("orders-2", "orders", true); err != nil {
+		t.Fatal(err)
+	}
+	if instances, _ := registry.Discover(ctx, "orders"); len(instances) != 2 {
+		t.Fatalf("expected orders-2 back, got %v", instances)
+	}
+
+	if err := registry.SetReady("orders-3", "orders", true); !errors.Is(err, discovery.ErrNotRegistered) {
+		t.Fatalf("expected ErrNotRegistered for an unknown instance, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+// Package registry builds the discovery.Registry a service is configured to
+// use through its REGISTRY settings.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/juxue97/common"
+	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/discovery/consul"
+	"github.com/juxue97/common/discovery/dns"
+	"github.com/juxue97/common/discovery/file"
+)
+
+const grpcCheckInterval = time.Second
+
+type Config struct {
+	// Kind is "consul", "file" (File, hot reloaded) or "dns" (SRV records
+	// named by DNSFormat).
+	Kind       string
+	ConsulAddr string
+	// ConsulHealthCheck is "ttl" to heartbeat to Consul or "grpc" to let
+	// Consul poll grpc.health.v1.
+	ConsulHealthCheck string
+	File              string
+	DNSFormat         string
+	// TLS is set when the services serve gRPC over TLS, TLSCA when their
+	// certificates are signed by a configured CA and TLSMutual when they
+	// require client certificates.
+	TLS       bool
+	TLSCA     bool
+	TLSMutual bool
+}
+
+// ConfigFromEnv reads REGISTRY, CONSUL_ADDR, CONSUL_HEALTH_CHECK,
+// REGISTRY_FILE and REGISTRY_DNS_FORMAT, and TLS_CERT_FILE, TLS_CA_FILE and
+// TLS_MUTUAL for TLS.
+func ConfigFromEnv() Config {
+	return Config{
+		Kind:              common.GetString("REGISTRY", "consul"),
+		ConsulAddr:        common.GetString("CONSUL_ADDR", "localhost:8500"),
+		ConsulHealthCheck: common.GetString("CONSUL_HEALTH_CHECK", "ttl"),
+		File:              common.GetString("REGISTRY_FILE", "registry.yaml"),
+		DNSFormat:         common.GetString("REGISTRY_DNS_FORMAT", dns.DefaultFormat),
+		TLS:               common.GetString("TLS_CERT_FILE", "") != "",
+		TLSCA:             common.GetString("TLS_CA_FILE", "") != "",
+		TLSMutual:         common.GetBool("TLS_MUTUAL", false),
+	}
+}
+
+// FromEnv returns the registry configured by the environment.
+func FromEnv(serviceName string) (discovery.Registry, error) {
+	return New(serviceName, ConfigFromEnv())
+}
+
+func New(serviceName string, cfg Config) (discovery.Registry, error) {
+	switch cfg.Kind {
+	case "consul":
+		registry, err := consul.NewRegistry(cfg.ConsulAddr, serviceName)
+		if err != nil {
+			return nil, err
+		}
+		switch cfg.ConsulHealthCheck {
+		case "ttl":
+		case "grpc":
+			// Consul's gRPC check has no client certificate to present
+			if cfg.TLSMutual {
+				return nil, errors.New("CONSUL_HEALTH_CHECK=grpc can't check services with TLS_MUTUAL, use ttl")
+			}
+			// the Consul agent verifies with its own CA, which must be the one in TLS_CA_FILE
+			registry.EnableGRPCHealthCheck(grpcCheckInterval, cfg.TLS, !cfg.TLSCA)
+		default:
+			return nil, fmt.Errorf("unknown CONSUL_HEALTH_CHECK %q", cfg.ConsulHealthCheck)
+		}
+		return registry, nil
+	case "file":
+		return file.NewRegistry(cfg.File, 0)
+	case "dns":
+		return dns.NewRegistry(cfg.DNSFormat, 0), nil
+	default:
+		return nil, fmt.Errorf("unknown REGISTRY %q", cfg.Kind)
+	}
+}
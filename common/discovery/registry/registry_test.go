@@ -0,0 +1,92 @@
+package registry_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/juxue97/common/discovery/consul"
+	"github.com/juxue97/common/discovery/dns"
+	"github.com/juxue97/common/discovery/file"
+	"github.com/juxue97/common/discovery/registry"
+)
+
+func TestNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	if err := os.WriteFile(path, []byte("orders:\n  - localhost:8001\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     registry.Config
+		wantErr bool
+		check   func(any) bool
+	}{
+		{
+			name:  "consul with ttl checks",
+			cfg:   registry.Config{Kind: "consul", ConsulAddr: "localhost:8500", ConsulHealthCheck: "ttl"},
+			check: func(r any) bool { _, ok := r.(*consul.Registry); return ok },
+		},
+		{
+			name:  "consul with grpc checks",
+			cfg:   registry.Config{Kind: "consul", ConsulAddr: "localhost:8500", ConsulHealthCheck: "grpc"},
+			check: func(r any) bool { _, ok := r.(*consul.Registry); return ok },
+		},
+		{
+			name:    "consul with grpc checks and mutual TLS",
+			cfg:     registry.Config{Kind: "consul", ConsulAddr: "localhost:8500", ConsulHealthCheck: "grpc", TLS: true, TLSMutual: true},
+			wantErr: true,
+		},
+		{
+			name:  "consul with ttl checks and mutual TLS",
+			cfg:   registry.Config{Kind: "consul", ConsulAddr: "localhost:8500", ConsulHealthCheck: "ttl", TLS: true, TLSMutual: true},
+			check: func(r any) bool { _, ok := r.(*consul.Registry); return ok },
+		},
+		{
+			name:    "unknown consul health check",
+			cfg:     registry.Config{Kind: "consul", ConsulAddr: "localhost:8500", ConsulHealthCheck: "http"},
+			wantErr: true,
+		},
+		{
+			name:  "file",
+			cfg:   registry.Config{Kind: "file", File: path},
+			check: func(r any) bool { _, ok := r.(*file.Registry); return ok },
+		},
+		{
+			name:    "missing file",
+			cfg:     registry.Config{Kind: "file", File: filepath.Join(t.TempDir(), "missing.yaml")},
+			wantErr: true,
+		},
+		{
+			name:  "dns",
+			cfg:   registry.Config{Kind: "dns", DNSFormat: dns.DefaultFormat},
+			check: func(r any) bool { _, ok := r.(*dns.Registry); return ok },
+		},
+		{
+			name:    "unknown kind",
+			cfg:     registry.Config{Kind: "etcd"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := registry.New("orders", tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(r) {
+				t.Errorf("unexpected registry %T", r)
+			}
+			if f, ok := r.(*file.Registry); ok {
+				f.Close()
+			}
+		})
+	}
+}
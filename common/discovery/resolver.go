@@ -0,0 +1,121 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/juxue97/common"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme is the target scheme served by the registry resolver, as in
+// registry:///orders or, limited by a Selector, registry:///orders?version=v2.
+const Scheme = "registry"
+
+var (
+	ErrNoInstances = errors.New("no healthy instances")
+	ErrWatchClosed = errors.New("registry watch closed")
+)
+
+var watchBackoff = common.RetryConfig{
+	BaseDelay: 500 * time.Millisecond,
+	MaxDelay:  30 * time.Second,
+}
+
+type resolverBuilder struct {
+	registry Registry
+}
+
+// NewResolverBuilder returns a gRPC resolver that keeps the addresses of a
+// service up to date by watching registry.
+func NewResolverBuilder(registry Registry) resolver.Builder {
+	return &resolverBuilder{registry: registry}
+}
+
+func (b *resolverBuilder) Scheme() string {
+	return Scheme
+}
+
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &registryResolver{
+		registry:    b.registry,
+		serviceName: target.Endpoint(),
+		selector:    ParseSelector(target.URL.Query()),
+		cc:          cc,
+		cancel:      cancel,
+	}
+
+	r.wg.Add(1)
+	go r.watch(ctx)
+
+	return r, nil
+}
+
+type registryResolver struct {
+	registry    Registry
+	serviceName string
+	selector    Selector
+	cc          resolver.ClientConn
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// watch pushes the instances to gRPC as the registry reports them,
+// re-establishing the watch with backoff if it fails.
+func (r *registryResolver) watch(ctx context.Context) {
+	defer r.wg.Done()
+
+	for attempt := 1; ; attempt++ {
+		instances, err := r.registry.Watch(ctx, r.serviceName)
+		if err == nil {
+			for list := range instances {
+				attempt = 1
+				r.update(list)
+			}
+			err = ErrWatchClosed
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("failed to watch %s: %v", r.serviceName, err)
+		r.cc.ReportError(err)
+
+		select {
+		case <-time.After(watchBackoff.Backoff(attempt)):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *registryResolver) update(instances []Instance) {
+	instances = r.selector.Filter(instances)
+	if len(instances) == 0 {
+		r.cc.ReportError(fmt.Errorf("%w: %s", ErrNoInstances, r.serviceName))
+		return
+	}
+
+	addrs := make([]resolver.Address, len(instances))
+	for i, instance := range instances {
+		addrs[i] = resolver.Address{Addr: instance.Address}
+	}
+
+	if err := r.cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		log.Printf("failed to update %s addresses: %v", r.serviceName, err)
+	}
+}
+
+// ResolveNow is a no-op, the watch already delivers every change.
+func (r *registryResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *registryResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,125 @@
+package discovery_test
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/discovery/inmem"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func serve(t *testing.T, calls *atomic.Int32) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		calls.Add(1)
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+
+	return l.Addr().String()
+}
+
+func TestResolverBalancesAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	registry := inmem.NewRegistry()
+
+	var first, second atomic.Int32
+	registry.Register(ctx, discovery.Instance{ID: "orders-1", ServiceName: "orders", Address: serve(t, &first)})
+	registry.Register(ctx, discovery.Instance{ID: "orders-2", ServiceName: "orders", Address: serve(t, &second)})
+
+	conn, err := discovery.NewClient("orders", registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	check := func() {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// round robin only starts alternating once both subchannels are ready
+	deadline := time.Now().Add(time.Second)
+	for first.Load() == 0 || second.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("calls were not spread across instances: %d and %d", first.Load(), second.Load())
+		}
+		check()
+	}
+
+	registry.Deregister(ctx, "orders-2", "orders")
+	time.Sleep(50 * time.Millisecond)
+
+	before := second.Load()
+	for i := 0; i < 10; i++ {
+		check()
+	}
+	if got := second.Load(); got != before {
+		t.Errorf("expected no calls to the deregistered instance, got %d", got-before)
+	}
+}
+
+func TestSelectorClientOnlyUsesMatchingInstances(t *testing.T) {
+	ctx := context.Background()
+	registry := inmem.NewRegistry()
+
+	var stable, canary atomic.Int32
+	registry.Register(ctx, discovery.Instance{ID: "orders-1", ServiceName: "orders", Address: serve(t, &stable), Version: "v1"})
+	registry.Register(ctx, discovery.Instance{ID: "orders-2", ServiceName: "orders", Address: serve(t, &canary), Version: "v2", Tags: []string{"canary"}})
+
+	selector := discovery.Selector{Version: "v2", Tags: []string{"canary"}}
+	if got := discovery.ParseSelector(mustQuery(t, selector.Target("orders"))); !reflect.DeepEqual(got, selector) {
+		t.Fatalf("expected %+v to survive the target, got %+v", selector, got)
+	}
+
+	conn, err := discovery.NewSelectorClient("orders", selector, registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	for i := 0; i < 10; i++ {
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true))
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if stable.Load() != 0 || canary.Load() != 10 {
+		t.Errorf("expected every call on the canary, got stable=%d canary=%d", stable.Load(), canary.Load())
+	}
+}
+
+func mustQuery(t *testing.T, target string) url.Values {
+	t.Helper()
+
+	u, err := url.Parse(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
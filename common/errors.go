@@ -18,6 +18,8 @@ var (
 	ErrNoQuantity         = errors.New("quantity cannot less than 1")
 	ErrConvertID          = errors.New("failed to convert inserted ID to primitive.ObjectID")
 	ErrNoDoc              = errors.New("no document found")
+	ErrMixedCurrency      = errors.New("order items must share the same currency")
+	ErrAmountMismatch     = errors.New("paid amount does not match order total")
 )
 
 func BadRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
@@ -0,0 +1,189 @@
+// Package interceptor is the gRPC server middleware every service installs:
+// tracing through the otelgrpc stats handler, then request logging, per-call
+// metrics, panic recovery and request validation, outermost first.
+package interceptor
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const instrumentation = "github.com/juxue97/common/interceptor"
+
+// ServerOptions returns the server options installing the chain. Recovery
+// sits inside logging and metrics so recovered panics are logged and counted
+// as Internal errors.
+func ServerOptions(logger *zap.Logger) []grpc.ServerOption {
+	metrics := newMetrics()
+
+	return []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			UnaryLogging(logger),
+			metrics.unary,
+			UnaryRecovery(logger),
+			UnaryValidation(),
+		),
+		grpc.ChainStreamInterceptor(
+			StreamLogging(logger),
+			metrics.stream,
+			StreamRecovery(logger),
+		),
+	}
+}
+
+// UnaryRecovery turns a panicking handler into a codes.Internal error.
+func UnaryRecovery(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res any, err error) {
+		defer recoverPanic(ctx, logger, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+func StreamRecovery(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverPanic(ss.Context(), logger, info.FullMethod, &err)
+		return handler(srv, ss)
+	}
+}
+
+func recoverPanic(ctx context.Context, logger *zap.Logger, method string, err *error) {
+	if r := recover(); r != nil {
+		logger.Error("panic in grpc handler",
+			append(traceFields(ctx),
+				zap.String("method", method),
+				zap.Any("panic", r),
+				zap.ByteString("stack", debug.Stack()),
+			)...,
+		)
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+// UnaryLogging logs every call with its status, duration and trace IDs.
+func UnaryLogging(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+		logCall(ctx, logger, info.FullMethod, start, err)
+		return res, err
+	}
+}
+
+func StreamLogging(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), logger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logCall(ctx context.Context, logger *zap.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := append(traceFields(ctx),
+		zap.String("method", method),
+		zap.String("code", code.String()),
+		zap.Duration("took", time.Since(start)),
+	)
+
+	switch code {
+	case codes.OK:
+		logger.Info("grpc call", fields...)
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		logger.Error("grpc call", append(fields, zap.Error(err))...)
+	default:
+		logger.Warn("grpc call", append(fields, zap.Error(err))...)
+	}
+}
+
+func traceFields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []zap.Field{
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+	}
+}
+
+// Validator is implemented by requests that can check their own fields, as
+// the messages generated by protoc-gen-validate do.
+type Validator interface {
+	Validate() error
+}
+
+// UnaryValidation rejects invalid requests with codes.InvalidArgument before
+// they reach the handler. The Validate methods generated from the
+// (validate.rules) annotations in oms.proto do the checking.
+func UnaryValidation() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if v, ok := req.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+type metrics struct {
+	calls    metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+// newMetrics records to the global MeterProvider, which
+// common.SetGlobalTracer installs.
+func newMetrics() *metrics {
+	meter := otel.Meter(instrumentation)
+
+	calls, err := meter.Int64Counter("rpc.server.calls",
+		metric.WithDescription("gRPC calls handled, by method and status code"))
+	if err != nil {
+		otel.Handle(err)
+	}
+	duration, err := meter.Float64Histogram("rpc.server.call.duration",
+		metric.WithDescription("Duration of handled gRPC calls"),
+		metric.WithUnit("s"))
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &metrics{calls: calls, duration: duration}
+}
+
+func (m *metrics) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+	m.record(ctx, info.FullMethod, start, err)
+	return res, err
+}
+
+func (m *metrics) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	m.record(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func (m *metrics) record(ctx context.Context, method string, start time.Time, err error) {
+	attrs := metric.WithAttributes(
+		attribute.String("rpc.method", method),
+		attribute.String("rpc.grpc.status_code", status.Code(err).String()),
+	)
+	m.calls.Add(ctx, 1, attrs)
+	m.duration.Record(ctx, time.Since(start).Seconds(), attrs)
+}
@@ -0,0 +1,189 @@
+package interceptor_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	pb "github.com/juxue97/common/api"
+	"github.com/juxue97/common/interceptor"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type panickingHealth struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (panickingHealth) Check(context.Context, *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	panic("nil map")
+}
+
+func TestServerRecoversAndLogsWithTraceIDs(t *testing.T) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer(interceptor.ServerOptions(zap.New(core))...)
+	healthpb.RegisterHealthServer(s, panickingHealth{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected the panic to become Internal, got %v", err)
+	}
+
+	if logs.FilterMessage("panic in grpc handler").Len() != 1 {
+		t.Fatal("expected the panic to be logged")
+	}
+	calls := logs.FilterMessage("grpc call").All()
+	if len(calls) != 1 {
+		t.Fatalf("expected one call to be logged, got %d", len(calls))
+	}
+	fields := calls[0].ContextMap()
+	if fields["code"] != codes.Internal.String() || fields["method"] != "/grpc.health.v1.Health/Check" {
+		t.Errorf("unexpected call log %v", fields)
+	}
+	if fields["trace_id"] == nil {
+		t.Errorf("expected the call log to carry a trace id, got %v", fields)
+	}
+}
+
+func TestValidationRejectsInvalidRequests(t *testing.T) {
+	validate := interceptor.UnaryValidation()
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.OrderService/CreateOrder"}
+	handled := false
+	handler := func(ctx context.Context, req any) (any, error) {
+		handled = true
+		return nil, nil
+	}
+
+	invalid := &pb.CreateOrderRequest{
+		CustomerID: "c1",
+		Items:      []*pb.ItemsWithQuantity{{ID: "i1", Quantity: 0}},
+	}
+	if _, err := validate(context.Background(), invalid, info, handler); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	if handled {
+		t.Fatal("expected the handler not to run")
+	}
+
+	valid := &pb.CreateOrderRequest{
+		CustomerID: "c1",
+		Items:      []*pb.ItemsWithQuantity{{ID: "i1", Quantity: 2}},
+	}
+	if _, err := validate(context.Background(), valid, info, handler); err != nil || !handled {
+		t.Fatalf("expected a valid request through, got %v", err)
+	}
+}
+
+func TestValidationFollowsProtoRules(t *testing.T) {
+	validate := interceptor.UnaryValidation()
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.StockService/UpdateStockQuantity"}
+	handler := func(ctx context.Context, req any) (any, error) { return nil, nil }
+
+	tests := []struct {
+		req  any
+		want string
+	}{
+		{&pb.UpdateStockQuantityRequest{ID: "i1", Quantity: 0}, ""},
+		{&pb.UpdateStockQuantityRequest{ID: "i1", Quantity: -1}, "invalid UpdateStockQuantityRequest.Quantity: value must be greater than or equal to 0"},
+		{&pb.GetOrderRequest{OrderID: "o1"}, "invalid GetOrderRequest.CustomerID: value length must be at least 1 runes"},
+		{&pb.CreateOrderRequest{CustomerID: "c1"}, "invalid CreateOrderRequest.Items: value must contain at least 1 item(s)"},
+		{
+			&pb.CreateOrderRequest{CustomerID: "c1", Items: []*pb.ItemsWithQuantity{{ID: "i1", Quantity: 1}, {Quantity: 1}}},
+			"invalid CreateOrderRequest.Items[1]: embedded message failed validation | caused by: invalid ItemsWithQuantity.ID: value length must be at least 1 runes",
+		},
+		{&pb.Order{}, ""},
+	}
+	for _, tt := range tests {
+		_, err := validate(context.Background(), tt.req, info, handler)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%T: expected no error, got %v", tt.req, err)
+			}
+			continue
+		}
+		if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != tt.want {
+			t.Errorf("%T: expected InvalidArgument %q, got %v", tt.req, tt.want, err)
+		}
+	}
+}
+
+func TestServerRecordsCallMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer(interceptor.ServerOptions(zap.NewNop())...)
+	healthpb.RegisterHealthServer(s, panickingHealth{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for i := 0; i < 2; i++ {
+		healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	want := attribute.NewSet(
+		attribute.String("rpc.method", "/grpc.health.v1.Health/Check"),
+		attribute.String("rpc.grpc.status_code", codes.Internal.String()),
+	)
+	var calls, durations uint64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					if m.Name == "rpc.server.calls" && dp.Attributes.Equals(&want) {
+						calls += uint64(dp.Value)
+					}
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					if m.Name == "rpc.server.call.duration" && dp.Attributes.Equals(&want) {
+						durations += dp.Count
+					}
+				}
+			}
+		}
+	}
+	if calls != 2 || durations != 2 {
+		t.Errorf("expected 2 calls and 2 durations recorded, got %d and %d", calls, durations)
+	}
+}
@@ -0,0 +1,356 @@
+// Package lifecycle runs a service instance: it registers the instance,
+// heartbeats, reports readiness to the registry and over gRPC health from the
+// service's dependency checks, and on SIGINT or SIGTERM deregisters, drains
+// servers and workers and closes resources in order.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/juxue97/common/discovery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	defaultHeartbeat       = time.Second
+	defaultCheckTimeout    = time.Second
+	defaultShutdownTimeout = 30 * time.Second
+)
+
+// Check reports whether a dependency is ready to serve traffic.
+type Check func(ctx context.Context) error
+
+// Cached runs check at most once per interval and returns its last result in
+// between, for checks that call rate limited APIs.
+func Cached(check Check, interval time.Duration) Check {
+	var mu sync.Mutex
+	var last time.Time
+	var err error
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if last.IsZero() || time.Since(last) >= interval {
+			err = check(ctx)
+			last = time.Now()
+		}
+
+		return err
+	}
+}
+
+type Config struct {
+	Registry discovery.Registry
+	Instance discovery.Instance
+	// Heartbeat is how often the TTL check is refreshed, well inside its TTL.
+	Heartbeat    time.Duration
+	CheckTimeout time.Duration
+	// ShutdownTimeout bounds draining servers and workers before they are
+	// stopped forcefully.
+	ShutdownTimeout time.Duration
+}
+
+type named[T any] struct {
+	name string
+	fn   T
+}
+
+type server struct {
+	name     string
+	serve    func() error
+	shutdown func(ctx context.Context) error
+	stop     func()
+}
+
+type Lifecycle struct {
+	config  Config
+	checks  []named[Check]
+	servers []server
+	workers []named[func(ctx context.Context) error]
+	closers []named[func(ctx context.Context) error]
+
+	health   *health.Server
+	services []string
+}
+
+func New(config Config) *Lifecycle {
+	if config.Heartbeat <= 0 {
+		config.Heartbeat = defaultHeartbeat
+	}
+	if config.CheckTimeout <= 0 {
+		config.CheckTimeout = defaultCheckTimeout
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	return &Lifecycle{config: config}
+}
+
+// AddCheck adds a readiness check. The instance keeps heartbeating when a
+// check fails, but is marked not ready in the registry and reports
+// NOT_SERVING until every check passes again.
+func (l *Lifecycle) AddCheck(name string, check Check) {
+	l.checks = append(l.checks, named[Check]{name, check})
+}
+
+// AddGRPCServer serves s on lis until shutdown, then drains it with
+// GracefulStop. It also registers grpc.health.v1 on s, reporting the overall
+// status and that of each service already registered on s, so call it after
+// registering them.
+func (l *Lifecycle) AddGRPCServer(s *grpc.Server, lis net.Listener) {
+	if l.health == nil {
+		l.health = health.NewServer()
+		l.services = append(l.services, "")
+	}
+	for name := range s.GetServiceInfo() {
+		l.services = append(l.services, name)
+	}
+	healthpb.RegisterHealthServer(s, l.health)
+	l.setServing(false)
+
+	l.servers = append(l.servers, server{
+		name:  "grpc " + lis.Addr().String(),
+		serve: func() error { return s.Serve(lis) },
+		shutdown: func(ctx context.Context) error {
+			done := make(chan struct{})
+			go func() {
+				s.GracefulStop()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+		stop: s.Stop,
+	})
+}
+
+// AddHTTPServer serves s until shutdown, then drains it with Shutdown. It
+// serves HTTPS if s has a TLSConfig providing the certificate.
+func (l *Lifecycle) AddHTTPServer(s *http.Server) {
+	l.servers = append(l.servers, server{
+		name: "http " + s.Addr,
+		serve: func() error {
+			var err error
+			if s.TLSConfig != nil {
+				err = s.ListenAndServeTLS("", "")
+			} else {
+				err = s.ListenAndServe()
+			}
+			if !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		shutdown: s.Shutdown,
+		stop:     func() { s.Close() },
+	})
+}
+
+// Go runs fn, such as a broker consumer, until shutdown cancels its context,
+// and waits for it to return before resources are closed. If fn fails before
+// then, the instance shuts down as it does when a server fails.
+func (l *Lifecycle) Go(name string, fn func(ctx context.Context) error) {
+	l.workers = append(l.workers, named[func(ctx context.Context) error]{name, fn})
+}
+
+// AddCloser closes a resource after servers and workers have stopped.
+// Closers run in the order they were added, so add the broker before the
+// database its consumers write to.
+func (l *Lifecycle) AddCloser(name string, fn func(ctx context.Context) error) {
+	l.closers = append(l.closers, named[func(ctx context.Context) error]{name, fn})
+}
+
+// Run registers the instance and serves until ctx is done, a signal arrives
+// or a server or worker fails, then shuts everything down. It returns the
+// failure, if any.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	instance := l.config.Instance
+	if err := l.config.Registry.Register(ctx, instance); err != nil {
+		return fmt.Errorf("registering %s: %w", instance.ID, err)
+	}
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		l.heartbeat(heartbeatCtx)
+	}()
+
+	failed := make(chan error, len(l.servers)+len(l.workers))
+	for _, s := range l.servers {
+		go func() {
+			log.Printf("starting %s server", s.name)
+			if err := s.serve(); err != nil {
+				failed <- fmt.Errorf("%s server: %w", s.name, err)
+			}
+		}()
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for _, w := range l.workers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			if err := w.fn(workerCtx); err != nil {
+				failed <- fmt.Errorf("%s: %w", w.name, err)
+			}
+		}()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+		log.Printf("shutting down %s", instance.ID)
+	case err = <-failed:
+		log.Printf("shutting down %s: %v", instance.ID, err)
+	}
+
+	// deregister first so clients stop routing here while we drain
+	stopHeartbeat()
+	<-heartbeatDone
+	if l.health != nil {
+		l.health.Shutdown()
+	}
+	if err := l.config.Registry.Deregister(context.Background(), instance.ID, instance.ServiceName); err != nil {
+		log.Printf("failed to deregister %s: %v", instance.ID, err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), l.config.ShutdownTimeout)
+	defer cancel()
+
+	stopWorkers()
+	l.shutdownServers(shutdownCtx)
+
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		log.Printf("workers did not stop within %s", l.config.ShutdownTimeout)
+	}
+
+	for _, c := range l.closers {
+		if err := c.fn(shutdownCtx); err != nil {
+			log.Printf("failed to close %s: %v", c.name, err)
+		}
+	}
+
+	return err
+}
+
+func (l *Lifecycle) shutdownServers(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, s := range l.servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.shutdown(ctx); err != nil {
+				log.Printf("%s server did not drain, stopping: %v", s.name, err)
+				s.stop()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (l *Lifecycle) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(l.config.Heartbeat)
+	defer ticker.Stop()
+
+	instance := l.config.Instance
+	for {
+		err := l.ready(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		l.setServing(err == nil)
+		if err != nil {
+			log.Printf("%s not ready: %v", instance.ID, err)
+		}
+
+		// the heartbeat goes on while a dependency is down, so the registry
+		// keeps the instance and only leaves it out of discovery
+		l.report(ctx, "health check", func() error {
+			return l.config.Registry.HealthCheck(instance.ID, instance.ServiceName)
+		})
+		l.report(ctx, "report readiness of", func() error {
+			return l.config.Registry.SetReady(instance.ID, instance.ServiceName, err == nil)
+		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// report registers the instance again when the registry has dropped it, e.g.
+// after a pause longer than the deregister-after-critical timeout.
+func (l *Lifecycle) report(ctx context.Context, what string, fn func() error) {
+	instance := l.config.Instance
+
+	err := fn()
+	if errors.Is(err, discovery.ErrNotRegistered) {
+		log.Printf("%s is no longer registered, registering again", instance.ID)
+		if err = l.config.Registry.Register(ctx, instance); err == nil {
+			err = fn()
+		}
+	}
+	if err != nil {
+		log.Printf("failed to %s %s: %v", what, instance.ID, err)
+	}
+}
+
+func (l *Lifecycle) setServing(serving bool) {
+	if l.health == nil {
+		return
+	}
+
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	for _, name := range l.services {
+		l.health.SetServingStatus(name, status)
+	}
+}
+
+func (l *Lifecycle) ready(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, l.config.CheckTimeout)
+	defer cancel()
+
+	for _, c := range l.checks {
+		if err := c.fn(ctx); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,344 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/discovery/inmem"
+	"github.com/juxue97/common/lifecycle"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestLifecycleShutdownOrder(t *testing.T) {
+	registry := inmem.NewRegistry()
+	instance := discovery.Instance{ID: "orders-1", ServiceName: "orders", Address: "127.0.0.1:0"}
+
+	l := lifecycle.New(lifecycle.Config{
+		Registry:  registry,
+		Instance:  instance,
+		Heartbeat: 10 * time.Millisecond,
+	})
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.AddGRPCServer(grpc.NewServer(), lis)
+	l.AddHTTPServer(&http.Server{Addr: "127.0.0.1:0"})
+
+	var checks sync.WaitGroup
+	checks.Add(1)
+	var once sync.Once
+	l.AddCheck("mongo", func(ctx context.Context) error {
+		once.Do(checks.Done)
+		return nil
+	})
+
+	started := make(chan struct{})
+	l.Go("consumer", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		record("consumer drained")
+		return nil
+	})
+	l.AddCloser("broker", func(context.Context) error { record("broker closed"); return nil })
+	l.AddCloser("mongo", func(context.Context) error { record("mongo closed"); return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- l.Run(ctx) }()
+
+	<-started
+	checks.Wait()
+	if _, err := registry.Discover(ctx, "orders"); err != nil {
+		t.Fatalf("expected the instance to be registered: %v", err)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lifecycle did not shut down")
+	}
+
+	if _, err := registry.Discover(context.Background(), "orders"); err == nil {
+		t.Error("expected the instance to be deregistered")
+	}
+
+	want := "consumer drained, broker closed, mongo closed"
+	if got := strings.Join(events, ", "); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestLifecycleDeregistersOnShutdown(t *testing.T) {
+	registry := inmem.NewRegistry()
+	defer registry.Close()
+
+	other := discovery.Instance{ID: "stocks-2", ServiceName: "stocks", Address: "127.0.0.1:2"}
+	if err := registry.Register(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+
+	l := lifecycle.New(lifecycle.Config{
+		Registry:  registry,
+		Instance:  discovery.Instance{ID: "stocks-1", ServiceName: "stocks", Address: "127.0.0.1:1"},
+		Heartbeat: 10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- l.Run(ctx) }()
+
+	for len(discover(t, registry)) != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// the other replica keeps serving, this one is gone
+	instances := discover(t, registry)
+	if len(instances) != 1 || instances[0].ID != other.ID {
+		t.Fatalf("expected only %s after shutdown, got %v", other.ID, instances)
+	}
+}
+
+func TestLifecycleStaysRegisteredWhileNotReady(t *testing.T) {
+	registry := inmem.NewRegistryWithConfig(inmem.Config{
+		TTL:                     20 * time.Millisecond,
+		DeregisterCriticalAfter: 20 * time.Millisecond,
+	})
+	defer registry.Close()
+
+	l := lifecycle.New(lifecycle.Config{
+		Registry:  registry,
+		Instance:  discovery.Instance{ID: "stocks-1", ServiceName: "stocks", Address: "127.0.0.1:1"},
+		Heartbeat: 5 * time.Millisecond,
+	})
+
+	var mongoDown atomic.Bool
+	mongoDown.Store(true)
+	l.AddCheck("mongo", func(ctx context.Context) error {
+		if mongoDown.Load() {
+			return errors.New("mongo is down")
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx)
+
+	// well past the TTL and the deregister timeout
+	time.Sleep(100 * time.Millisecond)
+	if _, err := registry.Discover(ctx, "stocks"); err == nil {
+		t.Fatal("expected the unready instance to be left out of discovery")
+	}
+	if err := registry.HealthCheck("stocks-1", "stocks"); err != nil {
+		t.Fatalf("expected the unready instance to stay registered: %v", err)
+	}
+
+	mongoDown.Store(false)
+	waitDiscovered(t, registry)
+}
+
+func TestLifecycleRegistersAgain(t *testing.T) {
+	registry := inmem.NewRegistry()
+	defer registry.Close()
+
+	l := lifecycle.New(lifecycle.Config{
+		Registry:  registry,
+		Instance:  discovery.Instance{ID: "stocks-1", ServiceName: "stocks", Address: "127.0.0.1:1"},
+		Heartbeat: 5 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx)
+
+	waitDiscovered(t, registry)
+
+	// as if the registry had dropped the instance after a long pause
+	if err := registry.Deregister(ctx, "stocks-1", "stocks"); err != nil {
+		t.Fatal(err)
+	}
+	waitDiscovered(t, registry)
+}
+
+func waitDiscovered(t *testing.T, registry discovery.Registry) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := registry.Discover(context.Background(), "stocks"); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the instance was never discovered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func discover(t *testing.T, registry discovery.Registry) []discovery.Instance {
+	t.Helper()
+
+	instances, err := registry.Discover(context.Background(), "stocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return instances
+}
+
+func TestLifecycleServerFailure(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+
+	l := lifecycle.New(lifecycle.Config{
+		Registry: inmem.NewRegistry(),
+		Instance: discovery.Instance{ID: "gateway-1", ServiceName: "gateway"},
+	})
+	// the port is taken, so the server fails and Run shuts down
+	l.AddHTTPServer(&http.Server{Addr: lis.Addr().String()})
+
+	select {
+	case err := <-run(l):
+		var opErr *net.OpError
+		if !errors.As(err, &opErr) {
+			t.Fatalf("expected the listen error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lifecycle did not stop after the server failed")
+	}
+}
+
+func TestLifecycleWorkerFailure(t *testing.T) {
+	registry := inmem.NewRegistry()
+	defer registry.Close()
+
+	l := lifecycle.New(lifecycle.Config{
+		Registry:  registry,
+		Instance:  discovery.Instance{ID: "stocks-1", ServiceName: "stocks", Address: "127.0.0.1:1"},
+		Heartbeat: 10 * time.Millisecond,
+	})
+
+	consumerErr := errors.New("channel closed")
+	fail := make(chan struct{})
+	l.Go("consumer", func(ctx context.Context) error {
+		<-fail
+		return consumerErr
+	})
+	var closed atomic.Bool
+	l.AddCloser("broker", func(context.Context) error { closed.Store(true); return nil })
+
+	done := run(l)
+	for {
+		if _, err := registry.Discover(context.Background(), "stocks"); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(fail)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, consumerErr) {
+			t.Fatalf("expected the consumer error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lifecycle did not stop after the worker failed")
+	}
+
+	if _, err := registry.Discover(context.Background(), "stocks"); err == nil {
+		t.Error("expected the instance to be deregistered")
+	}
+	if !closed.Load() {
+		t.Error("expected the broker to be closed")
+	}
+}
+
+func TestLifecycleReportsGRPCHealth(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := lifecycle.New(lifecycle.Config{
+		Registry:  inmem.NewRegistry(),
+		Instance:  discovery.Instance{ID: "stocks-1", ServiceName: "stocks"},
+		Heartbeat: 10 * time.Millisecond,
+	})
+
+	var mongoDown atomic.Bool
+	mongoDown.Store(true)
+	l.AddCheck("mongo", func(ctx context.Context) error {
+		if mongoDown.Load() {
+			return errors.New("mongo is down")
+		}
+		return nil
+	})
+	l.AddGRPCServer(grpc.NewServer(), lis)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go l.Run(ctx)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	stream, err := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []healthpb.HealthCheckResponse_ServingStatus{
+		healthpb.HealthCheckResponse_NOT_SERVING,
+		healthpb.HealthCheckResponse_SERVING,
+	}
+	for _, status := range want {
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Status != status {
+			t.Fatalf("expected %s, got %s", status, res.Status)
+		}
+		mongoDown.Store(false)
+	}
+}
+
+func run(l *lifecycle.Lifecycle) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- l.Run(context.Background()) }()
+	return done
+}
@@ -0,0 +1,16 @@
+package common
+
+import (
+	"math"
+	"strings"
+)
+
+// ToMinorUnits converts a decimal amount such as 19.99 into the smallest
+// currency unit (1999), rounding rather than truncating.
+func ToMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func SameCurrency(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
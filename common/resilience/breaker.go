@@ -0,0 +1,225 @@
+package resilience
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/juxue97/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type State int
+
+const (
+	// StateClosed lets calls through and counts consecutive failures.
+	StateClosed State = iota
+	// StateOpen fails calls fast until the open timeout passes.
+	StateOpen
+	// StateHalfOpen lets one probe call through to decide whether to close.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type BreakerConfig struct {
+	// FailureThreshold is how many consecutive failures open the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before probing.
+	OpenTimeout time.Duration
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      10 * time.Second,
+	}
+}
+
+type Breaker struct {
+	name   string
+	config BreakerConfig
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// BreakerStatus is a snapshot of a breaker for monitoring.
+type BreakerStatus struct {
+	Name     string     `json:"name"`
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"openedAt,omitempty"`
+}
+
+var breakers = struct {
+	sync.Mutex
+	all []*Breaker
+}{}
+
+// NewBreaker returns a breaker for the calls to a service, listed by
+// Breakers.
+func NewBreaker(name string, config BreakerConfig) *Breaker {
+	defaults := DefaultBreakerConfig()
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = defaults.OpenTimeout
+	}
+
+	b := &Breaker{name: name, config: config}
+
+	breakers.Lock()
+	breakers.all = append(breakers.all, b)
+	breakers.Unlock()
+
+	return b
+}
+
+// Breakers reports the state of every breaker in the process.
+func Breakers() []BreakerStatus {
+	breakers.Lock()
+	all := append([]*Breaker(nil), breakers.all...)
+	breakers.Unlock()
+
+	res := make([]BreakerStatus, 0, len(all))
+	for _, b := range all {
+		res = append(res, b.Status())
+	}
+
+	return res
+}
+
+// HandleBreakers serves Breakers as JSON.
+func HandleBreakers(w http.ResponseWriter, r *http.Request) {
+	common.WriteJSON(w, http.StatusOK, Breakers())
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.current(time.Now())
+}
+
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := BreakerStatus{
+		Name:     b.name,
+		State:    b.current(time.Now()).String(),
+		Failures: b.failures,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		s.OpenedAt = &openedAt
+	}
+
+	return s
+}
+
+// UnaryClientInterceptor fails calls with codes.Unavailable while the breaker
+// is open. It wraps gRPC's own retries, so a call counts once however many
+// attempts it took.
+func (b *Breaker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !b.allow() {
+			return status.Errorf(codes.Unavailable, "%s circuit breaker is open", b.name)
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(err)
+
+		return err
+	}
+}
+
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.current(time.Now()) {
+	case StateClosed:
+		return true
+	case StateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// the caller gave up, which says nothing about the service
+	if status.Code(err) == codes.Canceled {
+		b.probing = false
+		return
+	}
+
+	if !isFailure(err) {
+		if b.state != StateClosed {
+			log.Printf("%s circuit breaker closed", b.name)
+		}
+		b.state = StateClosed
+		b.failures = 0
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.config.FailureThreshold {
+		if b.state != StateOpen {
+			log.Printf("%s circuit breaker opened after %d failures", b.name, b.failures)
+		}
+		b.state = StateOpen
+		b.openedAt = time.Now()
+		b.probing = false
+	}
+}
+
+// current must be called with the lock held.
+func (b *Breaker) current(now time.Time) State {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.config.OpenTimeout {
+		return StateHalfOpen
+	}
+
+	return b.state
+}
+
+// isFailure reports whether err says the service is unhealthy, as opposed to
+// rejecting the request itself.
+func isFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
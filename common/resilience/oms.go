@@ -0,0 +1,36 @@
+package resilience
+
+import (
+	"time"
+
+	pb "github.com/juxue97/common/api"
+)
+
+// Policies for the oms services. Every call gets a deadline; only reads are
+// retried, since a retried write may already have been applied.
+var (
+	OrderServiceMethods = []Method{
+		{Service: pb.OrderService_ServiceDesc.ServiceName, Timeout: 5 * time.Second},
+		read(pb.OrderService_ServiceDesc.ServiceName, "GetOrder"),
+		read(pb.OrderService_ServiceDesc.ServiceName, "GetOrderForStockUpdate"),
+	}
+
+	PromotionServiceMethods = []Method{
+		{Service: pb.PromotionService_ServiceDesc.ServiceName, Timeout: 5 * time.Second},
+		read(pb.PromotionService_ServiceDesc.ServiceName, "GetPromotion"),
+		read(pb.PromotionService_ServiceDesc.ServiceName, "ListPromotions"),
+	}
+
+	StockServiceMethods = []Method{
+		{Service: pb.StockService_ServiceDesc.ServiceName, Timeout: 5 * time.Second},
+		read(pb.StockService_ServiceDesc.ServiceName, "CheckIfItemsInStock"),
+		read(pb.StockService_ServiceDesc.ServiceName, "GetItems"),
+		read(pb.StockService_ServiceDesc.ServiceName, "GetStockItems"),
+		read(pb.StockService_ServiceDesc.ServiceName, "GetStockItem"),
+	}
+)
+
+func read(service, method string) Method {
+	retry := DefaultRetry
+	return Method{Service: service, Method: method, Timeout: 2 * time.Second, Retry: &retry}
+}
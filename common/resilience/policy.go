@@ -0,0 +1,131 @@
+// Package resilience protects gRPC clients from slow or failing services:
+// per-method deadlines and retries through the gRPC service config, and
+// circuit breakers that fail fast while a service is down.
+package resilience
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// RetryPolicy retries a call that failed with one of Codes. gRPC only retries
+// before any response arrives, so it suits idempotent methods only.
+type RetryPolicy struct {
+	MaxAttempts       int
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	BackoffMultiplier float64
+	Codes             []codes.Code
+}
+
+var DefaultRetry = RetryPolicy{
+	MaxAttempts:       3,
+	InitialBackoff:    100 * time.Millisecond,
+	MaxBackoff:        time.Second,
+	BackoffMultiplier: 2,
+	Codes:             []codes.Code{codes.Unavailable},
+}
+
+// Method configures calls to a method, or to every method of Service when
+// Method is empty. A method's own entry takes precedence over its service's.
+type Method struct {
+	Service string
+	Method  string
+	// Timeout is the deadline of calls whose context has none or a later one.
+	Timeout time.Duration
+	Retry   *RetryPolicy
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	Timeout     string       `json:"timeout,omitempty"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type serviceConfig struct {
+	// kept so the config doesn't drop the registry client's balancing
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig"`
+	MethodConfig        []methodConfig        `json:"methodConfig,omitempty"`
+}
+
+// ServiceConfig renders methods as a round-robin gRPC service config.
+func ServiceConfig(methods ...Method) string {
+	config := serviceConfig{
+		LoadBalancingConfig: []map[string]struct{}{{"round_robin": {}}},
+	}
+
+	for _, m := range methods {
+		mc := methodConfig{Name: []methodName{{Service: m.Service, Method: m.Method}}}
+		if m.Timeout > 0 {
+			mc.Timeout = duration(m.Timeout)
+		}
+		if r := m.Retry; r != nil {
+			rp := &retryPolicy{
+				MaxAttempts:       r.MaxAttempts,
+				InitialBackoff:    duration(r.InitialBackoff),
+				MaxBackoff:        duration(r.MaxBackoff),
+				BackoffMultiplier: r.BackoffMultiplier,
+			}
+			for _, code := range r.Codes {
+				rp.RetryableStatusCodes = append(rp.RetryableStatusCodes, codeName(code))
+			}
+			mc.RetryPolicy = rp
+		}
+		config.MethodConfig = append(config.MethodConfig, mc)
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(b)
+}
+
+// DialOptions applies methods and fails fast through breaker. Pass them
+// ahead of any service config of your own.
+func DialOptions(breaker *Breaker, methods ...Method) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithDefaultServiceConfig(ServiceConfig(methods...)),
+		grpc.WithChainUnaryInterceptor(breaker.UnaryClientInterceptor()),
+	}
+}
+
+func duration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// codeName turns codes.DeadlineExceeded into DEADLINE_EXCEEDED, the form
+// the service config expects.
+func codeName(code codes.Code) string {
+	var b strings.Builder
+	prev := ' '
+	for _, r := range code.String() {
+		if unicode.IsUpper(r) && unicode.IsLower(prev) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+		prev = r
+	}
+
+	return b.String()
+}
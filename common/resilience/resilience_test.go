@@ -0,0 +1,134 @@
+package resilience_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/resilience"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type flakyHealth struct {
+	healthpb.UnimplementedHealthServer
+	calls    atomic.Int32
+	failures atomic.Int32
+}
+
+func (h *flakyHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	h.calls.Add(1)
+	if h.failures.Add(-1) >= 0 {
+		return nil, status.Error(codes.Unavailable, "overloaded")
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func TestRetryThenBreak(t *testing.T) {
+	server := &flakyHealth{}
+	client := dial(t, server, resilience.BreakerConfig{FailureThreshold: 2, OpenTimeout: 100 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// two failures are retried away within one call
+	server.failures.Store(2)
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("expected the retries to succeed, got %v", err)
+	}
+	if got := server.calls.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+
+	// calls that fail every attempt open the breaker
+	server.failures.Store(1 << 20)
+	for range 2 {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.Unavailable {
+			t.Fatalf("expected Unavailable, got %v", err)
+		}
+	}
+
+	calls := server.calls.Load()
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the open breaker to fail fast, got %v", err)
+	}
+	if server.calls.Load() != calls {
+		t.Fatal("expected the open breaker not to call the service")
+	}
+	if got := stateOf("health"); got != "open" {
+		t.Fatalf("expected the breaker to be reported open, got %s", got)
+	}
+
+	// once the service recovers a probe closes it again
+	server.failures.Store(0)
+	time.Sleep(150 * time.Millisecond)
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	if got := stateOf("health"); got != "closed" {
+		t.Fatalf("expected the breaker to close, got %s", got)
+	}
+}
+
+func TestBreakerIgnoresRequestErrors(t *testing.T) {
+	breaker := resilience.NewBreaker("promotions", resilience.BreakerConfig{FailureThreshold: 1})
+	intercept := breaker.UnaryClientInterceptor()
+
+	notFound := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		return status.Error(codes.NotFound, "no such promotion")
+	}
+	for range 3 {
+		intercept(context.Background(), "/api.PromotionService/GetPromotion", nil, nil, nil, notFound)
+	}
+
+	if got := breaker.State(); got != resilience.StateClosed {
+		t.Fatalf("expected NotFound to leave the breaker closed, got %s", got)
+	}
+}
+
+func dial(t *testing.T, server healthpb.HealthServer, config resilience.BreakerConfig) healthpb.HealthClient {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, server)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	retry := resilience.DefaultRetry
+	retry.InitialBackoff = time.Millisecond
+	methods := []resilience.Method{
+		{Service: "grpc.health.v1.Health", Timeout: time.Second},
+		{Service: "grpc.health.v1.Health", Method: "Check", Timeout: time.Second, Retry: &retry},
+	}
+
+	breaker := resilience.NewBreaker("health", config)
+	opts := append(resilience.DialOptions(breaker, methods...), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(lis.Addr().String(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+// stateOf reports the most recent breaker named name.
+func stateOf(name string) string {
+	state := ""
+	for _, b := range resilience.Breakers() {
+		if b.Name == name {
+			state = b.State
+		}
+	}
+	return state
+}
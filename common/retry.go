@@ -0,0 +1,57 @@
+package common
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff returns the exponential delay (with full jitter) before the given
+// attempt, attempt 1 being the first retry.
+func (c RetryConfig) Backoff(attempt int) time.Duration {
+	if c.BaseDelay <= 0 || attempt <= 0 {
+		return 0
+	}
+
+	delay := c.BaseDelay << (attempt - 1)
+	if c.MaxDelay > 0 && (delay > c.MaxDelay || delay <= 0) {
+		delay = c.MaxDelay
+	}
+
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+// Retry calls fn until it succeeds, returns an error that retryable rejects,
+// runs out of attempts or ctx is done. The last error is returned.
+func Retry(ctx context.Context, cfg RetryConfig, retryable func(error) bool, fn func(context.Context) error) error {
+	attempts := cfg.MaxAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(cfg.Backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+
+		err = fn(ctx)
+		if err == nil || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,104 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	cfg := RetryConfig{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 0, max: 0},
+		{attempt: 1, max: 100 * time.Millisecond},
+		{attempt: 2, max: 200 * time.Millisecond},
+		{attempt: 4, max: 800 * time.Millisecond},
+		{attempt: 5, max: time.Second},
+		// the shift overflows, which must still be capped
+		{attempt: 70, max: time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			d := cfg.Backoff(tt.attempt)
+			if d < 0 || d > tt.max || (tt.max > 0 && d == 0) {
+				t.Fatalf("attempt %d: expected a delay in (0, %v], got %v", tt.attempt, tt.max, d)
+			}
+		}
+	}
+
+	if d := (RetryConfig{}).Backoff(3); d != 0 {
+		t.Errorf("expected no delay without a base delay, got %v", d)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	cfg := RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	errTemporary := errors.New("temporary")
+	errPermanent := errors.New("permanent")
+	retryable := func(err error) bool { return errors.Is(err, errTemporary) }
+
+	t.Run("retries until it succeeds", func(t *testing.T) {
+		calls := 0
+		err := Retry(context.Background(), cfg, retryable, func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return errTemporary
+			}
+			return nil
+		})
+		if err != nil || calls != 3 {
+			t.Fatalf("expected success on the third call, got %v after %d", err, calls)
+		}
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		calls := 0
+		err := Retry(context.Background(), cfg, retryable, func(context.Context) error {
+			calls++
+			return errTemporary
+		})
+		if !errors.Is(err, errTemporary) || calls != 3 {
+			t.Fatalf("expected the last error after 3 calls, got %v after %d", err, calls)
+		}
+	})
+
+	t.Run("stops on errors that are not retryable", func(t *testing.T) {
+		calls := 0
+		err := Retry(context.Background(), cfg, retryable, func(context.Context) error {
+			calls++
+			return errPermanent
+		})
+		if !errors.Is(err, errPermanent) || calls != 1 {
+			t.Fatalf("expected one call, got %v after %d", err, calls)
+		}
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := Retry(ctx, RetryConfig{MaxAttempts: 5, BaseDelay: time.Hour}, retryable, func(context.Context) error {
+			calls++
+			cancel()
+			return errTemporary
+		})
+		if !errors.Is(err, errTemporary) || calls != 1 {
+			t.Fatalf("expected to stop after the cancelled call, got %v after %d", err, calls)
+		}
+	})
+
+	t.Run("calls once without attempts configured", func(t *testing.T) {
+		calls := 0
+		Retry(context.Background(), RetryConfig{}, retryable, func(context.Context) error {
+			calls++
+			return errTemporary
+		})
+		if calls != 1 {
+			t.Fatalf("expected one call, got %d", calls)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v81"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+type StripeConfig struct {
+	// Timeout bounds each attempt of a Stripe request.
+	Timeout time.Duration
+	Retry   RetryConfig
+}
+
+func DefaultStripeConfig() StripeConfig {
+	return StripeConfig{
+		Timeout: 10 * time.Second,
+		Retry: RetryConfig{
+			MaxAttempts: 3,
+			BaseDelay:   200 * time.Millisecond,
+			MaxDelay:    5 * time.Second,
+		},
+	}
+}
+
+// SetupStripe sets the API key and turns off the client's own network
+// retries, which would otherwise multiply the attempts CallStripe makes.
+func SetupStripe(key string) {
+	stripe.Key = key
+	stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+		MaxNetworkRetries: stripe.Int64(0),
+	}))
+}
+
+// ProcessorError is returned by processors when a call to the payment
+// provider fails. It matches both its Kind sentinel and the underlying
+// provider error.
+type ProcessorError struct {
+	Op   string
+	Kind error
+	Err  error
+}
+
+func (e *ProcessorError) Error() string {
+	return fmt.Sprintf("%v (%s): %v", e.Kind, e.Op, e.Err)
+}
+
+func (e *ProcessorError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// CallStripe runs fn inside a span, retrying retryable Stripe errors with
+// backoff. Every attempt gets its own timeout and shares one idempotency key
+// so a retried create never produces duplicates on Stripe's side.
+func CallStripe(ctx context.Context, cfg StripeConfig, op string, kind error, fn func(ctx context.Context, idempotencyKey string) error) error {
+	ctx, span := otel.Tracer("stripe").Start(ctx, "Stripe - "+op)
+	defer span.End()
+
+	idempotencyKey := uuid.NewString()
+	attempts := 0
+
+	err := Retry(ctx, cfg.Retry, isStripeRetryable, func(ctx context.Context) error {
+		attempts++
+		if cfg.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+			defer cancel()
+		}
+		return fn(ctx, idempotencyKey)
+	})
+	span.SetAttributes(attribute.Int("stripe.attempts", attempts))
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return &ProcessorError{Op: op, Kind: kind, Err: err}
+	}
+
+	return nil
+}
+
+func isStripeRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) {
+		switch {
+		case stripeErr.HTTPStatusCode == http.StatusTooManyRequests,
+			stripeErr.HTTPStatusCode == http.StatusConflict,
+			stripeErr.HTTPStatusCode >= http.StatusInternalServerError:
+			return true
+		case stripeErr.Type == stripe.ErrorTypeAPI:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
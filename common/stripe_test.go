@@ -0,0 +1,58 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stripe/stripe-go/v81"
+)
+
+func TestIsStripeRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limited", &stripe.Error{HTTPStatusCode: http.StatusTooManyRequests}, true},
+		{"conflict", &stripe.Error{HTTPStatusCode: http.StatusConflict}, true},
+		{"server error", &stripe.Error{HTTPStatusCode: http.StatusBadGateway}, true},
+		{"api error", &stripe.Error{Type: stripe.ErrorTypeAPI}, true},
+		{"card declined", &stripe.Error{HTTPStatusCode: http.StatusPaymentRequired, Type: stripe.ErrorTypeCard}, false},
+		{"invalid request", &stripe.Error{HTTPStatusCode: http.StatusBadRequest, Type: stripe.ErrorTypeInvalidRequest}, false},
+		{"wrapped", fmt.Errorf("creating price: %w", &stripe.Error{HTTPStatusCode: http.StatusServiceUnavailable}), true},
+		{"timeout", context.DeadlineExceeded, true},
+		{"cancelled", context.Canceled, false},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isStripeRetryable(tt.err); got != tt.want {
+				t.Errorf("isStripeRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallStripeWrapsTheLastError(t *testing.T) {
+	errCreate := errors.New("failed to create price")
+	cfg := StripeConfig{Retry: RetryConfig{MaxAttempts: 3}}
+
+	var keys []string
+	err := CallStripe(context.Background(), cfg, "price.New", errCreate, func(ctx context.Context, key string) error {
+		keys = append(keys, key)
+		return &stripe.Error{HTTPStatusCode: http.StatusInternalServerError}
+	})
+
+	var stripeErr *stripe.Error
+	if !errors.Is(err, errCreate) || !errors.As(err, &stripeErr) {
+		t.Fatalf("expected the kind and the Stripe error, got %v", err)
+	}
+	if len(keys) != 3 || keys[0] != keys[1] || keys[1] != keys[2] {
+		t.Fatalf("expected 3 attempts sharing an idempotency key, got %v", keys)
+	}
+}
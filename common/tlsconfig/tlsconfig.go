@@ -0,0 +1,246 @@
+// Package tlsconfig loads the TLS certificates services serve and dial with,
+// and reloads them when the files change so certificates can be rotated
+// without a restart.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+const defaultReload = 10 * time.Second
+
+var ErrNoCertificate = errors.New("tlsconfig: no certificate configured")
+
+// Config names the PEM files to load. Services read them from TLS_CERT_FILE,
+// TLS_KEY_FILE and TLS_CA_FILE and Mutual from TLS_MUTUAL. TLS is off unless
+// TLS_CERT_FILE is set, and TLS_MUTUAL also requires client certificates
+// signed by TLS_CA_FILE.
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// CAFile verifies peers. Without it servers accept any client and
+	// clients trust the system roots.
+	CAFile string
+	// Mutual makes gRPC servers require a client certificate signed by the
+	// CA.
+	Mutual bool
+	Reload time.Duration
+}
+
+type Certificates struct {
+	config Config
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime map[string]time.Time
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Load reads the configured files and keeps reloading them until Close.
+func Load(config Config) (*Certificates, error) {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, ErrNoCertificate
+	}
+	if config.Mutual && config.CAFile == "" {
+		return nil, errors.New("tlsconfig: mutual TLS needs a CA file")
+	}
+	if config.Reload <= 0 {
+		config.Reload = defaultReload
+	}
+
+	c := &Certificates{
+		config:  config,
+		modTime: map[string]time.Time{},
+		done:    make(chan struct{}),
+	}
+
+	if _, err := c.load(); err != nil {
+		return nil, err
+	}
+
+	go c.watch()
+
+	return c, nil
+}
+
+// Close stops reloading the files.
+func (c *Certificates) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return nil
+}
+
+// ServerConfig serves the current certificate, verifying client
+// certificates against the CA when verifyClients is set. Nothing is resolved
+// through GetConfigForClient, so the protocols http.Server and gRPC add to
+// NextProtos are still negotiated.
+func (c *Certificates) ServerConfig(verifyClients bool) *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// resolved per handshake so reloaded certificates and CAs apply to
+		// new connections
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, _ := c.current()
+			return cert, nil
+		},
+	}
+
+	if verifyClients {
+		// ClientCAs is fixed once the config is in use, so verify by hand
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("tlsconfig: client sent no certificate")
+			}
+			return c.verify(cs.PeerCertificates, "", x509.ExtKeyUsageClientAuth)
+		}
+	}
+
+	return config
+}
+
+// ClientConfig presents the current certificate to servers that ask for one
+// and verifies servers against the CA.
+func (c *Certificates) ClientConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := c.current()
+			return cert, nil
+		},
+	}
+
+	if c.config.CAFile != "" {
+		// RootCAs is fixed once the config is in use, so verify by hand
+		// against whichever CA is loaded at handshake time
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("tlsconfig: server sent no certificate")
+			}
+			return c.verify(cs.PeerCertificates, cs.ServerName, x509.ExtKeyUsageServerAuth)
+		}
+	}
+
+	return config
+}
+
+// verify checks a peer's chain against the CA loaded now.
+func (c *Certificates) verify(chain []*x509.Certificate, dnsName string, usage x509.ExtKeyUsage) error {
+	_, pool := c.current()
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		DNSName:       dnsName,
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	})
+	return err
+}
+
+// ServerCredentials are the gRPC server credentials, mutual if configured.
+func (c *Certificates) ServerCredentials() credentials.TransportCredentials {
+	return credentials.NewTLS(c.ServerConfig(c.config.Mutual))
+}
+
+// ClientCredentials are the gRPC client credentials. Servers are verified
+// against the service name being dialed, so service certificates must carry
+// it as a DNS name.
+func (c *Certificates) ClientCredentials() credentials.TransportCredentials {
+	return credentials.NewTLS(c.ClientConfig())
+}
+
+func (c *Certificates) current() (*tls.Certificate, *x509.CertPool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cert, c.pool
+}
+
+func (c *Certificates) watch() {
+	ticker := time.NewTicker(c.config.Reload)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := c.load()
+		if err != nil {
+			// keep serving the last good certificate
+			log.Printf("failed to reload certificates: %v", err)
+			continue
+		}
+		if changed {
+			log.Printf("reloaded certificate %s", c.config.CertFile)
+		}
+	}
+}
+
+// load reads the files if any changed since the last load.
+func (c *Certificates) load() (bool, error) {
+	files := []string{c.config.CertFile, c.config.KeyFile}
+	if c.config.CAFile != "" {
+		files = append(files, c.config.CAFile)
+	}
+
+	modTime := map[string]time.Time{}
+	changed := false
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+		modTime[file] = info.ModTime()
+		if !info.ModTime().Equal(c.modTime[file]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.config.CertFile, c.config.KeyFile)
+	if err != nil {
+		return false, err
+	}
+
+	var pool *x509.CertPool
+	if c.config.CAFile != "" {
+		pem, err := os.ReadFile(c.config.CAFile)
+		if err != nil {
+			return false, err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return false, fmt.Errorf("tlsconfig: no certificates in %s", c.config.CAFile)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cert = &cert
+	c.pool = pool
+	c.modTime = modTime
+
+	return true, nil
+}
@@ -0,0 +1,186 @@
+package tlsconfig_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/juxue97/common/tlsconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	if err := tlsconfig.GenerateDev(dir, "orders", "gateway"); err != nil {
+		t.Fatal(err)
+	}
+
+	server := load(t, dir, "orders", true)
+	client := load(t, dir, "gateway", false)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer(grpc.Creds(server.ServerCredentials()))
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(lis)
+	defer s.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// services verify each other by name, like the registry resolver dials
+	conn, err := grpc.NewClient(lis.Addr().String(),
+		grpc.WithTransportCredentials(client.ClientCredentials()),
+		grpc.WithAuthority("orders"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("expected the mutual handshake to succeed: %v", err)
+	}
+
+	// a client trusting the CA but without a certificate is turned away
+	anonymous := client.ClientConfig()
+	anonymous.GetClientCertificate = nil
+	anonymous.ServerName = "orders"
+	tlsConn, err := tls.Dial("tcp", lis.Addr().String(), anonymous)
+	if err == nil {
+		// TLS 1.3 reports the rejected certificate on the first read
+		_, err = tlsConn.Read(make([]byte, 1))
+		tlsConn.Close()
+	}
+	if err == nil {
+		t.Fatal("expected a client without a certificate to be rejected")
+	}
+}
+
+func TestCertificatesReload(t *testing.T) {
+	dir := t.TempDir()
+	if err := tlsconfig.GenerateDev(dir, "orders", "gateway"); err != nil {
+		t.Fatal(err)
+	}
+
+	server := load(t, dir, "orders", false)
+	client := load(t, dir, "gateway", false)
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", server.ServerConfig(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}()
+		}
+	}()
+
+	serial := func() string {
+		config := client.ClientConfig()
+		config.ServerName = "orders"
+		conn, err := tls.Dial("tcp", lis.Addr().String(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.String()
+	}
+
+	before := serial()
+
+	// rotate the certificate, signed by the same CA
+	if err := tlsconfig.GenerateDev(dir, "orders"); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	for _, file := range []string{"orders.pem", "orders-key.pem"} {
+		if err := os.Chtimes(filepath.Join(dir, file), later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for serial() == before {
+		if time.Now().After(deadline) {
+			t.Fatal("the rotated certificate was never served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHTTPServerNegotiatesHTTP2(t *testing.T) {
+	dir := t.TempDir()
+	if err := tlsconfig.GenerateDev(dir, "gateway", "client"); err != nil {
+		t.Fatal(err)
+	}
+
+	server := load(t, dir, "gateway", false)
+	client := load(t, dir, "client", false)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &http.Server{
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSConfig: server.ServerConfig(false),
+	}
+	go s.ServeTLS(lis, "", "")
+	defer s.Close()
+
+	for _, h2 := range []bool{true, false} {
+		config := client.ClientConfig()
+		config.ServerName = "gateway"
+		transport := &http.Transport{TLSClientConfig: config, ForceAttemptHTTP2: h2}
+		if !h2 {
+			transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+
+		res, err := (&http.Client{Transport: transport}).Get("https://" + lis.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		transport.CloseIdleConnections()
+
+		if want := map[bool]int{true: 2, false: 1}[h2]; res.ProtoMajor != want {
+			t.Errorf("expected HTTP/%d, got %s", want, res.Proto)
+		}
+	}
+}
+
+func load(t *testing.T, dir, service string, mutual bool) *tlsconfig.Certificates {
+	t.Helper()
+
+	certs, err := tlsconfig.Load(tlsconfig.Config{
+		CertFile: filepath.Join(dir, service+".pem"),
+		KeyFile:  filepath.Join(dir, service+"-key.pem"),
+		CAFile:   filepath.Join(dir, "ca.pem"),
+		Mutual:   mutual,
+		Reload:   10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { certs.Close() })
+
+	return certs
+}
@@ -4,14 +4,20 @@ import (
 	"context"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
+// SetGlobalTracer installs the global TracerProvider, exporting spans over
+// OTLP to exportAddr, and the global MeterProvider, exporting metrics over
+// OTLP to METRICS_ADDR. Jaeger only takes traces, so METRICS_ADDR should
+// point at a collector; it defaults to exportAddr.
 func SetGlobalTracer(ctx context.Context, serviceName, exportAddr string) error {
 	client := otlptracehttp.NewClient(
 		otlptracehttp.WithInsecure(),
@@ -23,16 +29,35 @@ func SetGlobalTracer(ctx context.Context, serviceName, exportAddr string) error
 		return err
 	}
 
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String(serviceName),
+	)
+
 	tp := trace.NewTracerProvider(
 		trace.WithBatcher(exporter),
-		trace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(serviceName),
-		)),
+		trace.WithResource(res),
 	)
 
 	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagation.TraceContext{})
 
+	return setGlobalMeter(ctx, res, GetString("METRICS_ADDR", exportAddr))
+}
+
+func setGlobalMeter(ctx context.Context, res *resource.Resource, exportAddr string) error {
+	exporter, err := otlpmetrichttp.New(ctx,
+		otlpmetrichttp.WithInsecure(),
+		otlpmetrichttp.WithEndpoint(exportAddr),
+	)
+	if err != nil {
+		return err
+	}
+
+	otel.SetMeterProvider(metric.NewMeterProvider(
+		metric.WithReader(metric.NewPeriodicReader(exporter)),
+		metric.WithResource(res),
+	))
+
 	return nil
 }
@@ -19,3 +19,11 @@ type StocksGateway interface {
 	UpdateStockQuantity(ctx context.Context, id string, quantity int) (*pb.StockItem, error)
 	DeleteItem(ctx context.Context, id string) error
 }
+
+type PromotionsGateway interface {
+	CreatePromotion(ctx context.Context, p *pb.Promotion) (*pb.Promotion, error)
+	GetPromotions(ctx context.Context) ([]*pb.Promotion, error)
+	GetPromotion(ctx context.Context, id string) (*pb.Promotion, error)
+	UpdatePromotion(ctx context.Context, id string, p *pb.Promotion) (*pb.Promotion, error)
+	DeletePromotion(ctx context.Context, id string) error
+}
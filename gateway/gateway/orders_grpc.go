@@ -2,43 +2,123 @@ package gateway
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"math/rand"
 
 	pb "github.com/juxue97/common/api"
 	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/resilience"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 )
 
 var orderServiceName = "orders"
 
+// Canary routes Percent of the calls to a service to the instances
+// Selector matches, and the rest to the instances Stable matches.
+type Canary struct {
+	Stable   discovery.Selector
+	Selector discovery.Selector
+	Percent  int
+}
+
 type ordersGateway struct {
-	registry discovery.Registry
+	conns         []*grpc.ClientConn
+	client        pb.OrderServiceClient
+	canary        pb.OrderServiceClient
+	canaryConn    connState
+	canaryBreaker *resilience.Breaker
+	canaryPercent int
 }
 
-func NewOrdersGateway(registry discovery.Registry) *ordersGateway {
-	return &ordersGateway{registry: registry}
+// connState is implemented by *grpc.ClientConn.
+type connState interface {
+	GetState() connectivity.State
 }
 
-func (g *ordersGateway) CreateOrder(ctx context.Context, payload *pb.CreateOrderRequest) (*pb.Order, error) {
-	conn, err := discovery.ServiceConnection(context.Background(), orderServiceName, g.registry)
+func NewOrdersGateway(registry discovery.Registry, canary Canary, opts ...grpc.DialOption) (*ordersGateway, error) {
+	stable, err := canary.stable()
 	if err != nil {
-		log.Fatalf("Failed to dial server: %v", err)
+		return nil, err
 	}
-	c := pb.NewOrderServiceClient(conn)
 
-	return c.CreateOrder(ctx, &pb.CreateOrderRequest{
-		CustomerID: payload.CustomerID,
-		Items:      payload.Items,
-	})
+	breaker := resilience.NewBreaker(orderServiceName, resilience.BreakerConfig{})
+	conn, err := discovery.NewSelectorClient(orderServiceName, stable, registry, append(resilience.DialOptions(breaker, resilience.OrderServiceMethods...), opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	g := &ordersGateway{conns: []*grpc.ClientConn{conn}, client: pb.NewOrderServiceClient(conn)}
+
+	if canary.Percent > 0 {
+		// a failing canary trips its own breaker, not the stable one
+		canaryBreaker := resilience.NewBreaker(orderServiceName+" canary", resilience.BreakerConfig{})
+		canaryConn, err := discovery.NewSelectorClient(orderServiceName, canary.Selector, registry, append(resilience.DialOptions(canaryBreaker, resilience.OrderServiceMethods...), opts...)...)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		// resolve now, so a canary without instances is noticed before its first call
+		canaryConn.Connect()
+
+		g.conns = append(g.conns, canaryConn)
+		g.canary = pb.NewOrderServiceClient(canaryConn)
+		g.canaryConn = canaryConn
+		g.canaryBreaker = canaryBreaker
+		g.canaryPercent = canary.Percent
+	}
+
+	return g, nil
 }
 
-func (g *ordersGateway) GetOrder(ctx context.Context, orderID string, customerID string) (*pb.Order, error) {
-	conn, err := discovery.ServiceConnection(context.Background(), orderServiceName, g.registry)
-	if err != nil {
-		log.Fatalf("Failed to dial server: %v", err)
+// stable returns the selector of the stable pool, which must leave the
+// canary's instances out for Percent to hold.
+func (c Canary) stable() (discovery.Selector, error) {
+	if c.Percent <= 0 {
+		return c.Stable, nil
+	}
+
+	switch {
+	case c.Selector.Version == "":
+		return discovery.Selector{}, errors.New("a canary needs a version to tell its instances apart")
+	case c.Stable.Version == c.Selector.Version:
+		return discovery.Selector{}, fmt.Errorf("the stable and canary versions are both %s", c.Selector.Version)
 	}
-	c := pb.NewOrderServiceClient(conn)
 
-	return c.GetOrder(ctx, &pb.GetOrderRequest{
+	stable := c.Stable
+	if stable.Version == "" {
+		stable.ExcludeVersion = c.Selector.Version
+	}
+	return stable, nil
+}
+
+func (g *ordersGateway) Close() error {
+	var errs []error
+	for _, conn := range g.conns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// pick sends the canary its share of calls while it can take them. When it
+// has no reachable instances or its breaker is open, the stable instances
+// serve the calls instead of failing them.
+func (g *ordersGateway) pick() pb.OrderServiceClient {
+	if g.canary == nil || rand.Intn(100) >= g.canaryPercent {
+		return g.client
+	}
+	if g.canaryBreaker.State() == resilience.StateOpen || g.canaryConn.GetState() == connectivity.TransientFailure {
+		return g.client
+	}
+	return g.canary
+}
+
+func (g *ordersGateway) CreateOrder(ctx context.Context, payload *pb.CreateOrderRequest) (*pb.Order, error) {
+	return g.pick().CreateOrder(ctx, payload)
+}
+
+func (g *ordersGateway) GetOrder(ctx context.Context, orderID string, customerID string) (*pb.Order, error) {
+	return g.pick().GetOrder(ctx, &pb.GetOrderRequest{
 		OrderID:    orderID,
 		CustomerID: customerID,
 	})
@@ -0,0 +1,105 @@
+package gateway
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	pb "github.com/juxue97/common/api"
+	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/resilience"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+type fakeOrdersClient struct {
+	pb.OrderServiceClient
+	name string
+}
+
+type fakeConnState connectivity.State
+
+func (s *fakeConnState) GetState() connectivity.State {
+	return connectivity.State(*s)
+}
+
+func TestPickFallsBackFromAnUnusableCanary(t *testing.T) {
+	stable, canary := &fakeOrdersClient{name: "stable"}, &fakeOrdersClient{name: "canary"}
+	state := fakeConnState(connectivity.Ready)
+	breaker := resilience.NewBreaker("orders canary test", resilience.BreakerConfig{FailureThreshold: 1})
+
+	g := &ordersGateway{
+		client:        stable,
+		canary:        canary,
+		canaryConn:    &state,
+		canaryBreaker: breaker,
+		canaryPercent: 100,
+	}
+
+	if g.pick() != canary {
+		t.Fatal("expected a healthy canary to take its share")
+	}
+
+	state = fakeConnState(connectivity.TransientFailure)
+	if g.pick() != stable {
+		t.Error("expected the stable client while the canary has no reachable instances")
+	}
+
+	state = fakeConnState(connectivity.Ready)
+	fail := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "down")
+	}
+	breaker.UnaryClientInterceptor()(context.Background(), "/api.OrderService/CreateOrder", nil, nil, nil, fail)
+	if g.pick() != stable {
+		t.Error("expected the stable client while the canary breaker is open")
+	}
+
+	g.canaryPercent = 0
+	if g.pick() != stable {
+		t.Error("expected no canary calls at 0 percent")
+	}
+}
+
+func TestCanaryStableSelector(t *testing.T) {
+	v1 := discovery.Instance{Version: "v1"}
+	v2 := discovery.Instance{Version: "v2"}
+
+	// no stable version set, so only the canary's is left out
+	stable, err := Canary{Selector: discovery.Selector{Version: "v2"}, Percent: 10}.stable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stable.Matches(v1) || stable.Matches(v2) {
+		t.Errorf("expected the stable pool to hold v1 and not the v2 canary, got %+v", stable)
+	}
+	if got := discovery.ParseSelector(mustQuery(t, stable.Target("orders"))); got.ExcludeVersion != "v2" {
+		t.Errorf("expected the exclusion to survive the target, got %+v", got)
+	}
+
+	// without a canary every instance is stable
+	stable, err = Canary{Selector: discovery.Selector{Version: "v2"}}.stable()
+	if err != nil || !stable.Matches(v2) {
+		t.Errorf("expected v2 in the stable pool without a canary, got %+v, %v", stable, err)
+	}
+
+	for _, c := range []Canary{
+		{Percent: 10},
+		{Stable: discovery.Selector{Version: "v2"}, Selector: discovery.Selector{Version: "v2"}, Percent: 10},
+	} {
+		if _, err := c.stable(); err == nil {
+			t.Errorf("expected %+v to be rejected", c)
+		}
+	}
+}
+
+func mustQuery(t *testing.T, target string) url.Values {
+	t.Helper()
+
+	u, err := url.Parse(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
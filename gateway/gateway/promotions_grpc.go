@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"context"
+
+	pb "github.com/juxue97/common/api"
+	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/resilience"
+	"google.golang.org/grpc"
+)
+
+// promotions are served by the orders service
+type promotionsGateway struct {
+	conn   *grpc.ClientConn
+	client pb.PromotionServiceClient
+}
+
+func NewPromotionsGateway(registry discovery.Registry, opts ...grpc.DialOption) (*promotionsGateway, error) {
+	breaker := resilience.NewBreaker("promotions", resilience.BreakerConfig{})
+	conn, err := discovery.NewClient(orderServiceName, registry, append(resilience.DialOptions(breaker, resilience.PromotionServiceMethods...), opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &promotionsGateway{conn: conn, client: pb.NewPromotionServiceClient(conn)}, nil
+}
+
+func (g *promotionsGateway) Close() error {
+	return g.conn.Close()
+}
+
+func (g *promotionsGateway) CreatePromotion(ctx context.Context, p *pb.Promotion) (*pb.Promotion, error) {
+	return g.client.CreatePromotion(ctx, p)
+}
+
+func (g *promotionsGateway) GetPromotions(ctx context.Context) ([]*pb.Promotion, error) {
+	res, err := g.client.ListPromotions(ctx, &pb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Promotions, nil
+}
+
+func (g *promotionsGateway) GetPromotion(ctx context.Context, id string) (*pb.Promotion, error) {
+	return g.client.GetPromotion(ctx, &pb.GetPromotionRequest{ID: id})
+}
+
+func (g *promotionsGateway) UpdatePromotion(ctx context.Context, id string, p *pb.Promotion) (*pb.Promotion, error) {
+	p.ID = id
+
+	return g.client.UpdatePromotion(ctx, p)
+}
+
+func (g *promotionsGateway) DeletePromotion(ctx context.Context, id string) error {
+	_, err := g.client.DeletePromotion(ctx, &pb.GetPromotionRequest{ID: id})
+	return err
+}
@@ -2,85 +2,61 @@ package gateway
 
 import (
 	"context"
-	"log"
 
 	pb "github.com/juxue97/common/api"
 	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/resilience"
+	"google.golang.org/grpc"
 )
 
 var stockServiceName = "stocks"
 
 type stocksGateway struct {
-	registry discovery.Registry
+	conn   *grpc.ClientConn
+	client pb.StockServiceClient
 }
 
-func NewStocksGateway(registry discovery.Registry) *stocksGateway {
-	return &stocksGateway{registry: registry}
-}
-
-func (g *stocksGateway) CreateItem(ctx context.Context, p *pb.CreateItemRequest) (*pb.CreateItemResponse, error) {
-	conn, err := discovery.ServiceConnection(context.Background(), stockServiceName, nil)
+func NewStocksGateway(registry discovery.Registry, opts ...grpc.DialOption) (*stocksGateway, error) {
+	breaker := resilience.NewBreaker(stockServiceName, resilience.BreakerConfig{})
+	conn, err := discovery.NewClient(stockServiceName, registry, append(resilience.DialOptions(breaker, resilience.StockServiceMethods...), opts...)...)
 	if err != nil {
-		log.Fatalf("Failed to dial server: %v", err)
+		return nil, err
 	}
-	c := pb.NewStockServiceClient(conn)
 
-	return c.CreateStockItem(ctx, p)
+	return &stocksGateway{conn: conn, client: pb.NewStockServiceClient(conn)}, nil
 }
 
-func (g *stocksGateway) GetItems(ctx context.Context) (*pb.GetStockItemsResponse, error) {
-	conn, err := discovery.ServiceConnection(context.Background(), stockServiceName, g.registry)
-	if err != nil {
-		log.Fatalf("Failed to dial server: %v", err)
-	}
-	c := pb.NewStockServiceClient(conn)
+func (g *stocksGateway) Close() error {
+	return g.conn.Close()
+}
 
-	return c.GetStockItems(ctx, nil)
+func (g *stocksGateway) CreateItem(ctx context.Context, p *pb.CreateItemRequest) (*pb.CreateItemResponse, error) {
+	return g.client.CreateStockItem(ctx, p)
 }
 
-func (g *stocksGateway) GetItem(ctx context.Context, id string) (*pb.StockItem, error) {
-	conn, err := discovery.ServiceConnection(context.Background(), stockServiceName, g.registry)
-	if err != nil {
-		log.Fatalf("Failed to dial server: %v", err)
-	}
-	c := pb.NewStockServiceClient(conn)
+func (g *stocksGateway) GetItems(ctx context.Context) (*pb.GetStockItemsResponse, error) {
+	return g.client.GetStockItems(ctx, nil)
+}
 
-	return c.GetStockItem(ctx, &pb.GetStockItemRequest{Id: id})
+func (g *stocksGateway) GetItem(ctx context.Context, id string) (*pb.StockItem, error) {
+	return g.client.GetStockItem(ctx, &pb.GetStockItemRequest{Id: id})
 }
 
 func (g *stocksGateway) UpdateItem(ctx context.Context, id string, p *pb.UpdateStockItemRequest) (*pb.StockItem, error) {
-	conn, err := discovery.ServiceConnection(context.Background(), stockServiceName, g.registry)
-	if err != nil {
-		log.Fatalf("Failed to dial server: %v", err)
-	}
-	c := pb.NewStockServiceClient(conn)
-
 	p.Id = id
 
-	return c.UpdateStockItem(ctx, p)
+	return g.client.UpdateStockItem(ctx, p)
 }
 
 func (g *stocksGateway) UpdateStockQuantity(ctx context.Context, id string, quantity int) (*pb.StockItem, error) {
-	conn, err := discovery.ServiceConnection(context.Background(), stockServiceName, g.registry)
-	if err != nil {
-		log.Fatalf("Failed to dial server: %v", err)
-	}
-	c := pb.NewStockServiceClient(conn)
-
-	return c.UpdateStockQuantity(ctx, &pb.UpdateStockQuantityRequest{
+	return g.client.UpdateStockQuantity(ctx, &pb.UpdateStockQuantityRequest{
 		ID:       id,
 		Quantity: int64(quantity),
 	})
 }
 
 func (g *stocksGateway) DeleteItem(ctx context.Context, id string) error {
-	conn, err := discovery.ServiceConnection(context.Background(), stockServiceName, g.registry)
-	if err != nil {
-		log.Fatalf("Failed to dial server: %v", err)
-	}
-	c := pb.NewStockServiceClient(conn)
-
-	_, err = c.DeleteItem(ctx, &pb.DeleteItemRequest{ID: id})
+	_, err := g.client.DeleteItem(ctx, &pb.DeleteItemRequest{ID: id})
 	if err != nil {
 		return err
 	}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/juxue97/common"
@@ -21,14 +24,19 @@ var Validate *validator.Validate = validator.New(validator.WithRequiredStructEna
 
 type handler struct {
 	// gateway - service discovery
-	ordersGateway gateway.OrdersGateway
-	stocksGateway gateway.StocksGateway
+	ordersGateway     gateway.OrdersGateway
+	stocksGateway     gateway.StocksGateway
+	promotionsGateway gateway.PromotionsGateway
+
+	promotionsAdminToken string
 }
 
-func NewHandler(ordersGateway gateway.OrdersGateway, stocksGateway gateway.StocksGateway) *handler {
+func NewHandler(ordersGateway gateway.OrdersGateway, stocksGateway gateway.StocksGateway, promotionsGateway gateway.PromotionsGateway, promotionsAdminToken string) *handler {
 	return &handler{
-		ordersGateway: ordersGateway,
-		stocksGateway: stocksGateway,
+		ordersGateway:        ordersGateway,
+		stocksGateway:        stocksGateway,
+		promotionsGateway:    promotionsGateway,
+		promotionsAdminToken: promotionsAdminToken,
 	}
 }
 
@@ -44,6 +52,28 @@ func (h *handler) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("PUT /stocks/{id}", h.handleUpdateItem)
 	mux.HandleFunc("PUT /stocks/{id}/{quantity}", h.handleUpdateStock)
 	mux.HandleFunc("DELETE /stocks/{id}", h.handleDeleteItem)
+
+	// promotions are admin only, reads included since they list every code
+	if h.promotionsAdminToken == "" {
+		log.Printf("PROMOTIONS_ADMIN_TOKEN is not set, the promotions API is disabled")
+		return
+	}
+	mux.HandleFunc("GET /promotions", h.authorize(h.handleGetPromotions))
+	mux.HandleFunc("GET /promotions/{id}", h.authorize(h.handleGetPromotion))
+	mux.HandleFunc("POST /promotions", h.authorize(h.handleCreatePromotion))
+	mux.HandleFunc("PUT /promotions/{id}", h.authorize(h.handleUpdatePromotion))
+	mux.HandleFunc("DELETE /promotions/{id}", h.authorize(h.handleDeletePromotion))
+}
+
+func (h *handler) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.promotionsAdminToken)) != 1 {
+			common.WriteError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next(w, r)
+	}
 }
 
 func validateItems(items []*pb.ItemsWithQuantity) error {
@@ -63,10 +93,26 @@ func validateItems(items []*pb.ItemsWithQuantity) error {
 	return nil
 }
 
+// orderErrorResponse maps the orders service's status codes to HTTP, with
+// retryMessage telling the client an Unavailable call is worth repeating.
+func orderErrorResponse(w http.ResponseWriter, r *http.Request, err error, retryMessage string) {
+	rStatus := status.Convert(err)
+	switch rStatus.Code() {
+	case codes.Unavailable:
+		common.WriteError(w, http.StatusServiceUnavailable, retryMessage)
+	case codes.NotFound:
+		common.NotFoundError(w, r, errors.New(rStatus.Message()))
+	case codes.InvalidArgument, codes.FailedPrecondition:
+		common.BadRequestResponse(w, r, errors.New(rStatus.Message()))
+	default:
+		common.InternalServerError(w, r, err)
+	}
+}
+
 func (h *handler) handleCreateOrder(w http.ResponseWriter, r *http.Request) {
 	customerID := r.PathValue("customerID")
-	var items []*pb.ItemsWithQuantity
-	if err := common.ReadJSON(w, r, &items); err != nil {
+	var payload createOrderRequest
+	if err := common.ReadJSON(w, r, &payload); err != nil {
 		common.BadRequestResponse(w, r, err)
 		return
 	}
@@ -75,24 +121,30 @@ func (h *handler) handleCreateOrder(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tr.Start(r.Context(), fmt.Sprintf("%s %s", r.Method, r.RequestURI))
 	defer span.End()
 
-	if err := validateItems(items); err != nil {
+	if err := validateItems(payload.Items); err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+	if err := validateShipping(&payload); err != nil {
 		common.BadRequestResponse(w, r, err)
 		return
 	}
 
+	if payload.DiscountCode == "" {
+		payload.DiscountCode = r.URL.Query().Get("discountCode")
+	}
+
 	o, err := h.ordersGateway.CreateOrder(ctx, &pb.CreateOrderRequest{
-		CustomerID: customerID,
-		Items:      items,
+		CustomerID:      customerID,
+		Items:           payload.Items,
+		DiscountCode:    payload.DiscountCode,
+		ShippingAddress: payload.ShippingAddress,
+		BillingAddress:  payload.BillingAddress,
+		ShippingMethod:  payload.ShippingMethod,
 	})
-	rStatus := status.Convert(err)
-	if rStatus != nil {
+	if err != nil {
 		span.SetStatus(otelCodes.Error, err.Error())
-
-		if rStatus.Code() != codes.InvalidArgument {
-			common.BadRequestResponse(w, r, errors.New(rStatus.Message()))
-			return
-		}
-		common.InternalServerError(w, r, err)
+		orderErrorResponse(w, r, err, "order could not be placed, please retry")
 		return
 	}
 
@@ -115,15 +167,9 @@ func (h *handler) handleGetOrder(w http.ResponseWriter, r *http.Request) {
 	defer span.End()
 
 	o, err := h.ordersGateway.GetOrder(ctx, orderID, customerID)
-	rStatus := status.Convert(err)
-	if rStatus != nil {
+	if err != nil {
 		span.SetStatus(otelCodes.Error, err.Error())
-
-		if rStatus.Code() != codes.InvalidArgument {
-			common.BadRequestResponse(w, r, errors.New(rStatus.Message()))
-			return
-		}
-		common.InternalServerError(w, r, err)
+		orderErrorResponse(w, r, err, "orders are unavailable, please retry")
 		return
 	}
 
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPromotionsNeedTheAdminToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{"no header", "secret", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "Bearer nope", http.StatusUnauthorized},
+		{"not configured", "", "Bearer ", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		mux := http.NewServeMux()
+		NewHandler(nil, nil, nil, tt.token).registerRoutes(mux)
+
+		for _, route := range []struct{ method, path string }{
+			{http.MethodGet, "/promotions"},
+			{http.MethodGet, "/promotions/p1"},
+			{http.MethodPost, "/promotions"},
+			{http.MethodPut, "/promotions/p1"},
+			{http.MethodDelete, "/promotions/p1"},
+		} {
+			method, path := route.method, route.path
+			req := httptest.NewRequest(method, path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("%s: %s %s: expected %d, got %d", tt.name, method, path, tt.want, rec.Code)
+			}
+		}
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/juxue97/common"
 	"github.com/juxue97/common/discovery"
-	"github.com/juxue97/common/discovery/consul"
+	"github.com/juxue97/common/discovery/registry"
+	"github.com/juxue97/common/lifecycle"
+	"github.com/juxue97/common/resilience"
+	"github.com/juxue97/common/tlsconfig"
 	"github.com/juxue97/gateway/gateway"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -17,7 +20,21 @@ var (
 
 	jaegerAddr = common.GetString("JAEGER_ADDR", "localhost:4318")
 	httpAddr   = common.GetString("HTTP_ADDR", ":8080")
-	consulAddr = common.GetString("CONSUL_ADDR", "localhost:8500")
+
+	// TLS_CERT_FILE serves HTTPS and dials services over TLS, verified by TLS_CA_FILE
+	tlsCertFile = common.GetString("TLS_CERT_FILE", "")
+	tlsKeyFile  = common.GetString("TLS_KEY_FILE", "")
+	tlsCAFile   = common.GetString("TLS_CA_FILE", "")
+
+	// send a share of order traffic to a canary version; an empty ORDERS_VERSION
+	// matches every instance but the canary's
+	ordersVersion       = common.GetString("ORDERS_VERSION", "")
+	ordersCanaryVersion = common.GetString("ORDERS_CANARY_VERSION", "")
+	ordersCanaryPercent = common.GetInt("ORDERS_CANARY_PERCENT", 0)
+
+	// bearer token for the promotions API; without it none of its routes
+	// are served
+	promotionsAdminToken = common.GetString("PROMOTIONS_ADMIN_TOKEN", "")
 )
 
 func main() {
@@ -26,38 +43,67 @@ func main() {
 		log.Fatal("failed to set global tracer")
 	}
 
-	// register service on consul
-	registry, err := consul.NewRegistry(consulAddr, serviceName)
+	reg, err := registry.FromEnv(serviceName)
 	if err != nil {
 		panic(err)
 	}
 
 	ctx := context.Background()
-	instanceID := discovery.GenerateInstanceID(serviceName)
-	if err := registry.Register(ctx, instanceID, serviceName, httpAddr); err != nil {
-		panic(err)
-	}
 
-	go func() {
-		for {
-			if err := registry.HealthCheck(instanceID, serviceName); err != nil {
-				log.Fatal("failed to health check")
-			}
-			time.Sleep(time.Second * 1)
+	httpServer := &http.Server{Addr: httpAddr}
+	var dialOpts []grpc.DialOption
+	if tlsCertFile != "" {
+		certs, err := tlsconfig.Load(tlsconfig.Config{
+			CertFile: tlsCertFile,
+			KeyFile:  tlsKeyFile,
+			CAFile:   tlsCAFile,
+		})
+		if err != nil {
+			log.Fatalf("failed to load certificates: %v", err)
 		}
-	}()
+		defer certs.Close()
 
-	defer registry.Deregister(ctx, instanceID, serviceName)
+		// browsers don't carry client certificates, only services do
+		httpServer.TLSConfig = certs.ServerConfig(false)
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(certs.ClientCredentials()))
+	}
 
 	// expose http server here, then grpc to other services
-	ordersGateway := gateway.NewOrdersGateway(registry)
-	stocksGateway := gateway.NewStocksGateway(registry)
+	ordersGateway, err := gateway.NewOrdersGateway(reg, gateway.Canary{
+		Stable:   discovery.Selector{Version: ordersVersion},
+		Selector: discovery.Selector{Version: ordersCanaryVersion},
+		Percent:  ordersCanaryPercent,
+	}, dialOpts...)
+	if err != nil {
+		log.Fatalf("failed to create orders gateway: %v", err)
+	}
+
+	stocksGateway, err := gateway.NewStocksGateway(reg, dialOpts...)
+	if err != nil {
+		log.Fatalf("failed to create stocks gateway: %v", err)
+	}
+
+	promotionsGateway, err := gateway.NewPromotionsGateway(reg, dialOpts...)
+	if err != nil {
+		log.Fatalf("failed to create promotions gateway: %v", err)
+	}
 
 	mux := http.NewServeMux()
-	handler := NewHandler(ordersGateway, stocksGateway)
+	handler := NewHandler(ordersGateway, stocksGateway, promotionsGateway, promotionsAdminToken)
 	handler.registerRoutes(mux)
+	mux.HandleFunc("GET /health/breakers", resilience.HandleBreakers)
+
+	app := lifecycle.New(lifecycle.Config{
+		Registry: reg,
+		Instance: discovery.NewInstance(serviceName, httpAddr, "http"),
+	})
+	httpServer.Handler = mux
+	app.AddHTTPServer(httpServer)
+	app.AddCloser("orders gateway", func(context.Context) error { return ordersGateway.Close() })
+	app.AddCloser("stocks gateway", func(context.Context) error { return stocksGateway.Close() })
+	app.AddCloser("promotions gateway", func(context.Context) error { return promotionsGateway.Close() })
 
-	if err := http.ListenAndServe(httpAddr, mux); err != nil {
-		log.Fatal("Failed to start the http server")
+	if err := app.Run(ctx); err != nil {
+		log.Fatalf("Failed to start the http server: %v", err)
 	}
 }
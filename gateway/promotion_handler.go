@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/juxue97/common"
+	pb "github.com/juxue97/common/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func validatePromotion(p *pb.Promotion) error {
+	if p == nil {
+		return errors.New("promotion is required")
+	}
+	if p.Code == "" {
+		return errors.New("promotion code is required")
+	}
+	if p.Type == "" {
+		return errors.New("promotion type is required")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.AsTime().After(p.StartsAt.AsTime()) {
+		return errors.New("promotion must end after it starts")
+	}
+
+	return nil
+}
+
+func promotionErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	rStatus := status.Convert(err)
+	switch rStatus.Code() {
+	case codes.NotFound:
+		common.NotFoundError(w, r, errors.New(rStatus.Message()))
+	case codes.InvalidArgument:
+		common.UnprocessableEntityResponse(w, r, errors.New(rStatus.Message()))
+	case codes.AlreadyExists:
+		common.DuplicateErrorResponse(w, r, errors.New(rStatus.Message()))
+	default:
+		common.InternalServerError(w, r, err)
+	}
+}
+
+func (h *handler) handleCreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var payload *pb.Promotion
+	if err := common.ReadJSON(w, r, &payload); err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+	if err := validatePromotion(payload); err != nil {
+		common.UnprocessableEntityResponse(w, r, err)
+		return
+	}
+
+	p, err := h.promotionsGateway.CreatePromotion(r.Context(), payload)
+	if err != nil {
+		promotionErrorResponse(w, r, err)
+		return
+	}
+
+	if err := common.WriteJSON(w, http.StatusCreated, p); err != nil {
+		common.InternalServerError(w, r, err)
+	}
+}
+
+func (h *handler) handleGetPromotions(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.promotionsGateway.GetPromotions(r.Context())
+	if err != nil {
+		promotionErrorResponse(w, r, err)
+		return
+	}
+
+	if err := common.WriteJSON(w, http.StatusOK, promotions); err != nil {
+		common.InternalServerError(w, r, err)
+	}
+}
+
+func (h *handler) handleGetPromotion(w http.ResponseWriter, r *http.Request) {
+	p, err := h.promotionsGateway.GetPromotion(r.Context(), r.PathValue("id"))
+	if err != nil {
+		promotionErrorResponse(w, r, err)
+		return
+	}
+
+	if err := common.WriteJSON(w, http.StatusOK, p); err != nil {
+		common.InternalServerError(w, r, err)
+	}
+}
+
+func (h *handler) handleUpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	var payload *pb.Promotion
+	if err := common.ReadJSON(w, r, &payload); err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+	if err := validatePromotion(payload); err != nil {
+		common.UnprocessableEntityResponse(w, r, err)
+		return
+	}
+
+	p, err := h.promotionsGateway.UpdatePromotion(r.Context(), r.PathValue("id"), payload)
+	if err != nil {
+		promotionErrorResponse(w, r, err)
+		return
+	}
+
+	if err := common.WriteJSON(w, http.StatusOK, p); err != nil {
+		common.InternalServerError(w, r, err)
+	}
+}
+
+func (h *handler) handleDeletePromotion(w http.ResponseWriter, r *http.Request) {
+	if err := h.promotionsGateway.DeletePromotion(r.Context(), r.PathValue("id")); err != nil {
+		promotionErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,8 +1,34 @@
 package main
 
-import pb "github.com/juxue97/common/api"
+import (
+	"bytes"
+	"encoding/json"
+
+	pb "github.com/juxue97/common/api"
+)
 
 type createOrderResponse struct {
-	Order         *pb.Order `"json":order`
-	RedirectToUrl string    `"json":redirectToUrl`
+	Order         *pb.Order `json:"Order"`
+	RedirectToUrl string    `json:"RedirectToUrl"`
+}
+
+type createOrderRequest struct {
+	Items           []*pb.ItemsWithQuantity `json:"items"`
+	DiscountCode    string                  `json:"discountCode"`
+	ShippingAddress *pb.Address             `json:"shippingAddress"`
+	BillingAddress  *pb.Address             `json:"billingAddress"`
+	ShippingMethod  string                  `json:"shippingMethod"`
+}
+
+// UnmarshalJSON also accepts the original body format, a bare array of items.
+func (c *createOrderRequest) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return json.Unmarshal(trimmed, &c.Items)
+	}
+
+	type request createOrderRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	return decoder.Decode((*request)(c))
 }
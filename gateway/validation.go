@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	pb "github.com/juxue97/common/api"
+)
+
+var (
+	countryCodeRegex    = regexp.MustCompile(`^[A-Z]{2}$`)
+	shippingMethodRegex = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+)
+
+func validateAddress(kind string, a *pb.Address) error {
+	if a == nil {
+		return nil
+	}
+
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+
+	switch {
+	case strings.TrimSpace(a.Line1) == "":
+		return fmt.Errorf("%s address line1 is required", kind)
+	case strings.TrimSpace(a.City) == "":
+		return fmt.Errorf("%s address city is required", kind)
+	case strings.TrimSpace(a.PostalCode) == "":
+		return fmt.Errorf("%s address postal code is required", kind)
+	case !countryCodeRegex.MatchString(a.Country):
+		return fmt.Errorf("%s address country must be a two-letter ISO code", kind)
+	}
+
+	return nil
+}
+
+func validateShipping(req *createOrderRequest) error {
+	if err := validateAddress("shipping", req.ShippingAddress); err != nil {
+		return err
+	}
+	if err := validateAddress("billing", req.BillingAddress); err != nil {
+		return err
+	}
+
+	if req.ShippingMethod != "" {
+		if !shippingMethodRegex.MatchString(req.ShippingMethod) {
+			return errors.New("invalid shipping method")
+		}
+		if req.ShippingAddress == nil {
+			return errors.New("shipping address is required with a shipping method")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	pb "github.com/juxue97/common/api"
+)
+
+func TestValidateShipping(t *testing.T) {
+	address := func() *pb.Address {
+		return &pb.Address{Line1: "1 Jalan Ampang", City: "Kuala Lumpur", PostalCode: "50450", Country: " my "}
+	}
+
+	tests := []struct {
+		name    string
+		req     *createOrderRequest
+		wantErr string
+	}{
+		{"no shipping", &createOrderRequest{}, ""},
+		{"shipping and billing", &createOrderRequest{ShippingAddress: address(), BillingAddress: address(), ShippingMethod: "express"}, ""},
+		{"missing line1", &createOrderRequest{ShippingAddress: &pb.Address{City: "KL", PostalCode: "50450", Country: "MY"}}, "shipping address line1 is required"},
+		{"missing city", &createOrderRequest{BillingAddress: &pb.Address{Line1: "1", PostalCode: "50450", Country: "MY"}}, "billing address city is required"},
+		{"missing postal code", &createOrderRequest{ShippingAddress: &pb.Address{Line1: "1", City: "KL", Country: "MY"}}, "shipping address postal code is required"},
+		{"bad country", &createOrderRequest{ShippingAddress: &pb.Address{Line1: "1", City: "KL", PostalCode: "50450", Country: "MYS"}}, "shipping address country must be a two-letter ISO code"},
+		{"bad method", &createOrderRequest{ShippingAddress: address(), ShippingMethod: "Next Day!"}, "invalid shipping method"},
+		{"method without address", &createOrderRequest{ShippingMethod: "standard"}, "shipping address is required with a shipping method"},
+	}
+	for _, tt := range tests {
+		err := validateShipping(tt.req)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: expected no error, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+
+	req := &createOrderRequest{ShippingAddress: address()}
+	if err := validateShipping(req); err != nil || req.ShippingAddress.Country != "MY" {
+		t.Errorf("expected the country normalised to MY, got %q, %v", req.ShippingAddress.Country, err)
+	}
+}
+
+func TestCreateOrderRequestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		items    int
+		discount string
+		wantErr  bool
+	}{
+		{"legacy array", `[{"ID":"a","Quantity":2},{"ID":"b","Quantity":1}]`, 2, "", false},
+		{"legacy array with whitespace", " \n\t[{\"ID\":\"a\",\"Quantity\":1}]", 1, "", false},
+		{"legacy empty array", `[]`, 0, "", false},
+		{"object", `{"items":[{"ID":"a","Quantity":2}],"discountCode":"SAVE10"}`, 1, "SAVE10", false},
+		{"unknown field", `{"items":[],"coupon":"SAVE10"}`, 0, "", true},
+		{"malformed array", `[{"ID":"a",}]`, 0, "", true},
+	}
+	for _, tt := range tests {
+		var req createOrderRequest
+		err := json.Unmarshal([]byte(tt.body), &req)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(req.Items) != tt.items || req.DiscountCode != tt.discount {
+			t.Errorf("%s: expected %d items and code %q, got %+v", tt.name, tt.items, tt.discount, req)
+		}
+	}
+}
@@ -2,34 +2,41 @@ package gateway
 
 import (
 	"context"
-	"log"
 
 	pb "github.com/juxue97/common/api"
 	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/resilience"
+	"google.golang.org/grpc"
 )
 
 var stockServiceName = "stocks"
 
 type gateway struct {
-	registry discovery.Registry
+	conn   *grpc.ClientConn
+	client pb.StockServiceClient
 }
 
-func NewGateway(registry discovery.Registry) *gateway {
-	return &gateway{registry: registry}
-}
-
-func (g *gateway) CheckIfItemsInStock(ctx context.Context, customerID string, items []*pb.ItemsWithQuantity) (bool, []*pb.Item, error) {
-	conn, err := discovery.ServiceConnection(context.Background(), stockServiceName, g.registry)
+func NewGateway(registry discovery.Registry, opts ...grpc.DialOption) (*gateway, error) {
+	breaker := resilience.NewBreaker(stockServiceName, resilience.BreakerConfig{})
+	conn, err := discovery.NewClient(stockServiceName, registry, append(resilience.DialOptions(breaker, resilience.StockServiceMethods...), opts...)...)
 	if err != nil {
-		log.Fatalf("Failed to dial server: %v", err)
+		return nil, err
 	}
-	defer conn.Close()
 
-	ordersClient := pb.NewStockServiceClient(conn)
+	return &gateway{conn: conn, client: pb.NewStockServiceClient(conn)}, nil
+}
 
-	res, err := ordersClient.CheckIfItemsInStock(ctx, &pb.CheckIfItemsInStockRequest{
+func (g *gateway) Close() error {
+	return g.conn.Close()
+}
+
+func (g *gateway) CheckIfItemsInStock(ctx context.Context, customerID string, items []*pb.ItemsWithQuantity) (bool, []*pb.Item, error) {
+	res, err := g.client.CheckIfItemsInStock(ctx, &pb.CheckIfItemsInStockRequest{
 		Items: items,
 	})
+	if err != nil {
+		return false, nil, err
+	}
 
-	return res.InStock, res.Items, err
+	return res.InStock, res.Items, nil
 }
@@ -2,84 +2,139 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 
-	amqp "github.com/rabbitmq/amqp091-go"
 	"go.opentelemetry.io/otel"
 
 	"github.com/juxue97/common"
 	pb "github.com/juxue97/common/api"
 	"github.com/juxue97/common/broker"
+	"github.com/juxue97/order/promotion"
+	"go.mongodb.org/mongo-driver/mongo"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type gRPCHandler struct {
 	pb.UnimplementedOrderServiceServer
-	service *loggingMiddleware
-	channel *amqp.Channel
+	service   OrderService
+	publisher broker.Publisher
 }
 
-func NewGRPCHandler(gRPCServer *grpc.Server, service *loggingMiddleware, channel *amqp.Channel) {
+func NewGRPCHandler(gRPCServer *grpc.Server, service OrderService, publisher broker.Publisher) {
 	handler := &gRPCHandler{
-		service: service,
-		channel: channel,
+		service:   service,
+		publisher: publisher,
 	}
 	pb.RegisterOrderServiceServer(gRPCServer, handler)
 }
 
 func (h *gRPCHandler) CreateOrder(ctx context.Context, payload *pb.CreateOrderRequest) (*pb.Order, error) {
-	q, err := h.channel.QueueDeclare(broker.OrderCreatedEvent, true, false, false, false, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	tr := otel.Tracer("amqp")
 	amqpContext, span := tr.Start(ctx, fmt.Sprintf(
-		"AMQP - publish - %s", q.Name,
+		"AMQP - publish - %s", broker.OrderCreatedEvent,
 	))
 
 	defer span.End()
 
 	items, err := h.service.validateOrder(amqpContext, payload)
 	if err != nil {
-		return nil, err
+		return nil, orderStatus(err)
 	}
 
 	o, err := h.service.createOrder(amqpContext, payload, items)
 	if err != nil {
-		return nil, err
+		return nil, orderStatus(err)
 	}
 	if len(o.Items) == 0 {
 		return nil, common.ErrNoDoc
 	}
 
-	marshalledOrder, err := json.Marshal(o)
+	event, err := broker.Events.NewEvent(amqpContext, serviceName, broker.OrderCreatedEvent, o)
 	if err != nil {
-		log.Fatal(err)
+		return nil, status.Errorf(codes.Internal, "failed to build %s event: %v", broker.OrderCreatedEvent, err)
 	}
 
-	headers := broker.InjectAMQPHeaders(amqpContext)
+	msg, err := broker.Events.Encode(amqpContext, event)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode %s event: %v", broker.OrderCreatedEvent, err)
+	}
 
-	h.channel.PublishWithContext(amqpContext, "", q.Name, false, false, amqp.Publishing{
-		ContentType:  "application/json",
-		Headers:      headers,
-		Body:         marshalledOrder,
-		DeliveryMode: amqp.Persistent,
-	})
+	// without the event no payment link is ever created, so the order is
+	// cancelled and the caller told to place it again
+	if err := h.publisher.Publish(amqpContext, "", broker.OrderCreatedEvent, msg); err != nil {
+		log.Printf("failed to publish %s for order %s: %v", broker.OrderCreatedEvent, o.ID, err)
+		span.RecordError(err)
+
+		// an unconfirmed event may still reach payment, so the order is left
+		// pending for reconciliation rather than cancelled under it
+		if errors.Is(err, broker.ErrConfirmTimeout) {
+			return nil, status.Errorf(codes.Unavailable, "order %s is pending, %s was not confirmed: %v", o.ID, broker.OrderCreatedEvent, err)
+		}
+
+		if cancelErr := h.service.cancelOrder(amqpContext, o); cancelErr != nil {
+			// the order may still look pending, so a retry could duplicate it
+			log.Printf("failed to cancel order %s: %v", o.ID, cancelErr)
+			return nil, status.Errorf(codes.Internal, "order %s was not confirmed: %v", o.ID, err)
+		}
+		return nil, status.Errorf(codes.Unavailable, "order %s was cancelled, failed to publish %s: %v", o.ID, broker.OrderCreatedEvent, err)
+	}
 
 	return o, nil
 }
 
 func (h *gRPCHandler) GetOrder(ctx context.Context, payload *pb.GetOrderRequest) (*pb.Order, error) {
-	return h.service.getOrder(ctx, payload)
+	o, err := h.service.getOrder(ctx, payload)
+	return o, orderStatus(err)
 }
 
 func (h *gRPCHandler) UpdateOrder(ctx context.Context, payload *pb.Order) (*pb.Order, error) {
-	return h.service.updateOrder(ctx, payload)
+	o, err := h.service.updateOrder(ctx, payload)
+	return o, orderStatus(err)
+}
+
+// CancelOrder cancels an order whose payment will never come, such as one
+// whose checkout session expired.
+func (h *gRPCHandler) CancelOrder(ctx context.Context, payload *pb.GetOrderRequest) (*pb.Order, error) {
+	o, err := h.service.getOrder(ctx, payload)
+	if err != nil {
+		return nil, orderStatus(err)
+	}
+	if err := h.service.cancelOrder(ctx, o); err != nil {
+		return nil, orderStatus(err)
+	}
+
+	return o, nil
 }
 
 func (h *gRPCHandler) GetOrderForStockUpdate(ctx context.Context, payload *pb.GetOrderRequest) (*pb.Order, error) {
 	return h.service.getOrderForStock(ctx, payload)
 }
+
+// orderStatus gives the errors a customer can fix a client code, so the
+// gateway answers with a 4xx. Errors already carrying a status, such as those
+// from the stock service, are passed on unchanged.
+func orderStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, common.ErrNoDoc), errors.Is(err, mongo.ErrNoDocuments), errors.Is(err, promotion.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, errInsufficientStock), errors.Is(err, errOrderClosed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, promotion.ErrInactive),
+		errors.Is(err, promotion.ErrNotStarted),
+		errors.Is(err, promotion.ErrExpired),
+		errors.Is(err, promotion.ErrUsageLimit),
+		errors.Is(err, promotion.ErrMinimumNotMet),
+		errors.Is(err, promotion.ErrNotApplicable):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
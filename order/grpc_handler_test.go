@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/juxue97/common"
+	pb "github.com/juxue97/common/api"
+	"github.com/juxue97/common/broker"
+	"github.com/juxue97/order/promotion"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestOrderStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{nil, codes.OK},
+		{fmt.Errorf("%w: SAVE10", promotion.ErrNotFound), codes.NotFound},
+		{common.ErrNoDoc, codes.NotFound},
+		{promotion.ErrExpired, codes.InvalidArgument},
+		{promotion.ErrUsageLimit, codes.InvalidArgument},
+		{errInsufficientStock, codes.FailedPrecondition},
+		{fmt.Errorf("updating order o1: %w", errOrderClosed), codes.FailedPrecondition},
+		{status.Error(codes.Unavailable, "stocks are down"), codes.Unavailable},
+		{errors.New("connection reset"), codes.Internal},
+	}
+	for _, tt := range tests {
+		if got := status.Code(orderStatus(tt.err)); got != tt.want {
+			t.Errorf("orderStatus(%v): expected %s, got %s", tt.err, tt.want, got)
+		}
+	}
+}
+
+type createdOrders struct {
+	OrderService
+	cancelled []string
+}
+
+func (s *createdOrders) validateOrder(ctx context.Context, payload *pb.CreateOrderRequest) ([]*pb.Item, error) {
+	return []*pb.Item{{ID: "i1", Quantity: 1}}, nil
+}
+
+func (s *createdOrders) createOrder(ctx context.Context, payload *pb.CreateOrderRequest, items []*pb.Item) (*pb.Order, error) {
+	return &pb.Order{ID: "o1", Status: "pending", Items: items}, nil
+}
+
+func (s *createdOrders) cancelOrder(ctx context.Context, o *pb.Order) error {
+	s.cancelled = append(s.cancelled, o.ID)
+	return nil
+}
+
+type failingPublisher struct {
+	err error
+}
+
+func (p failingPublisher) Publish(ctx context.Context, exchange, routingKey string, msg broker.Message) error {
+	return p.err
+}
+
+func TestCreateOrderKeepsUnconfirmedOrdersPending(t *testing.T) {
+	tests := []struct {
+		err       error
+		cancelled bool
+	}{
+		{broker.ErrConfirmTimeout, false},
+		{broker.ErrNacked, true},
+	}
+	for _, tt := range tests {
+		service := &createdOrders{}
+		h := &gRPCHandler{service: service, publisher: failingPublisher{err: tt.err}}
+
+		_, err := h.CreateOrder(context.Background(), &pb.CreateOrderRequest{CustomerID: "c1"})
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("%v: expected Unavailable, got %v", tt.err, err)
+		}
+		if cancelled := len(service.cancelled) > 0; cancelled != tt.cancelled {
+			t.Errorf("%v: expected cancelled %t, got %v", tt.err, tt.cancelled, service.cancelled)
+		}
+	}
+}
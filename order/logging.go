@@ -56,3 +56,51 @@ func (s *loggingMiddleware) updateOrder(ctx context.Context, o *pb.Order) (*pb.O
 	}()
 	return s.next.updateOrder(ctx, o)
 }
+
+func (s *loggingMiddleware) cancelOrder(ctx context.Context, o *pb.Order) error {
+	start := time.Now()
+	defer func() {
+		zap.L().Info("CancelOrder", zap.Duration("took", time.Since(start)))
+	}()
+	return s.next.cancelOrder(ctx, o)
+}
+
+func (s *loggingMiddleware) createPromotion(ctx context.Context, p *pb.Promotion) (*pb.Promotion, error) {
+	start := time.Now()
+	defer func() {
+		zap.L().Info("CreatePromotion", zap.Duration("took", time.Since(start)))
+	}()
+	return s.next.createPromotion(ctx, p)
+}
+
+func (s *loggingMiddleware) getPromotion(ctx context.Context, id string) (*pb.Promotion, error) {
+	start := time.Now()
+	defer func() {
+		zap.L().Info("GetPromotion", zap.Duration("took", time.Since(start)))
+	}()
+	return s.next.getPromotion(ctx, id)
+}
+
+func (s *loggingMiddleware) listPromotions(ctx context.Context) ([]*pb.Promotion, error) {
+	start := time.Now()
+	defer func() {
+		zap.L().Info("ListPromotions", zap.Duration("took", time.Since(start)))
+	}()
+	return s.next.listPromotions(ctx)
+}
+
+func (s *loggingMiddleware) updatePromotion(ctx context.Context, p *pb.Promotion) (*pb.Promotion, error) {
+	start := time.Now()
+	defer func() {
+		zap.L().Info("UpdatePromotion", zap.Duration("took", time.Since(start)))
+	}()
+	return s.next.updatePromotion(ctx, p)
+}
+
+func (s *loggingMiddleware) deletePromotion(ctx context.Context, id string) error {
+	start := time.Now()
+	defer func() {
+		zap.L().Info("DeletePromotion", zap.Duration("took", time.Since(start)))
+	}()
+	return s.next.deletePromotion(ctx, id)
+}
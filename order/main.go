@@ -2,16 +2,24 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"time"
 
 	"github.com/juxue97/common"
 	"github.com/juxue97/common/broker"
 	mongoConn "github.com/juxue97/common/db"
 	"github.com/juxue97/common/discovery"
-	"github.com/juxue97/common/discovery/consul"
+	"github.com/juxue97/common/discovery/registry"
+	"github.com/juxue97/common/interceptor"
+	"github.com/juxue97/common/lifecycle"
+	"github.com/juxue97/common/tlsconfig"
 	"github.com/juxue97/order/gateway"
+	"github.com/juxue97/order/tax"
+	stripeTax "github.com/juxue97/order/tax/stripe"
+	tableTax "github.com/juxue97/order/tax/table"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
@@ -21,15 +29,39 @@ var (
 
 	jaegerAddr = common.GetString("JAEGER_ADDR", "localhost:4318")
 	gRPCAddr   = common.GetString("GRPC_ADDR", "localhost:8001")
-	consulAddr = common.GetString("CONSUL_ADDR", "localhost:8500")
 	amqpUser   = common.GetString("RABBITMQ_USER", "juxue")
 	amqpPass   = common.GetString("RABBITMQ_PASS", "veryStrongPassword")
 	amqpHost   = common.GetString("RABBITMQ_HOST", "localhost")
 	amqpPort   = common.GetString("RABBITMQ_PORT", "5672")
 
+	tlsCertFile = common.GetString("TLS_CERT_FILE", "")
+	tlsKeyFile  = common.GetString("TLS_KEY_FILE", "")
+	tlsCAFile   = common.GetString("TLS_CA_FILE", "")
+	tlsMutual   = common.GetBool("TLS_MUTUAL", false)
+
+	retryMaxAttempts = common.GetInt("RETRY_MAX_ATTEMPTS", 3)
+	retryBaseDelay   = common.GetInt("RETRY_BASE_DELAY_MS", 1000)
+	retryMaxDelay    = common.GetInt("RETRY_MAX_DELAY_MS", 60000)
+
+	consumerWorkers  = common.GetInt("CONSUMER_WORKERS", 4)
+	consumerPrefetch = common.GetInt("CONSUMER_PREFETCH", 16)
+	consumerTimeout  = common.GetInt("CONSUMER_TIMEOUT_MS", 30000)
+
+	publishConfirmTimeout = common.GetInt("PUBLISH_CONFIRM_TIMEOUT_MS", 5000)
+
+	// "envelope", "cloudevents-binary" or "cloudevents-structured"
+	eventEncoding = common.GetString("EVENT_ENCODING", "envelope")
+
 	mongoUser = common.GetString("MONGO_DB_USER", "juxue")
 	mongoPass = common.GetString("MONGO_DB_PASS", "veryStrongPassword")
 	mongoHost = common.GetString("MONGO_DB_HOST", "localhost:27017")
+
+	// "stripe" leaves tax to Stripe Tax at checkout, "table" uses TAX_RATES
+	taxProvider    = common.GetString("TAX_PROVIDER", "stripe")
+	taxRates       = common.GetString("TAX_RATES", "")
+	taxDefaultRate = common.GetString("TAX_DEFAULT_RATE", "0")
+	// read by the payment service; checked here so table tax isn't charged twice
+	stripeAutomaticTax = common.GetBool("STRIPE_AUTOMATIC_TAX", false)
 )
 
 func main() {
@@ -46,32 +78,31 @@ func main() {
 		logger.Fatal("failed to set global tracer", zap.Error(err))
 	}
 
-	registry, err := consul.NewRegistry(consulAddr, serviceName)
+	reg, err := registry.FromEnv(serviceName)
 	if err != nil {
 		panic(err)
 	}
 
 	ctx := context.Background()
-	instanceID := discovery.GenerateInstanceID(serviceName)
-	if err := registry.Register(ctx, instanceID, serviceName, gRPCAddr); err != nil {
-		panic(err)
+
+	amqpBroker, err := broker.Connect(ctx, amqpUser, amqpPass, amqpHost, amqpPort)
+	if err != nil {
+		logger.Fatal("failed to connect to rabbitmq", zap.Error(err))
 	}
+	amqpBroker.SetConfirmTimeout(time.Duration(publishConfirmTimeout) * time.Millisecond)
 
-	go func() {
-		for {
-			if err := registry.HealthCheck(instanceID, serviceName); err != nil {
-				logger.Error("failed to health check", zap.Error(err))
-			}
-			time.Sleep(time.Second * 1)
-		}
-	}()
-	defer registry.Deregister(ctx, instanceID, serviceName)
+	retryPolicy := broker.RetryPolicy{
+		MaxAttempts: retryMaxAttempts,
+		BaseDelay:   time.Duration(retryBaseDelay) * time.Millisecond,
+		MaxDelay:    time.Duration(retryMaxDelay) * time.Millisecond,
+	}
+	if err := broker.SubscriberTopology(serviceName, broker.OrderPaidEvent, retryPolicy).Apply(amqpBroker); err != nil {
+		logger.Fatal("failed to declare broker topology", zap.Error(err))
+	}
 
-	ch, close := broker.Connect(amqpUser, amqpPass, amqpHost, amqpPort)
-	defer func() {
-		close()
-		ch.Close()
-	}()
+	if err := broker.Events.SetEncoding(broker.Encoding(eventEncoding)); err != nil {
+		logger.Fatal("failed to configure event encoding", zap.Error(err))
+	}
 
 	// MongoDB Conn
 	mongoURI := fmt.Sprintf("mongodb://%s:%s@%s", mongoUser, mongoPass, mongoHost)
@@ -79,35 +110,102 @@ func main() {
 	if err != nil {
 		logger.Fatal("failed to connect to mongo", zap.Error(err))
 	}
-	defer func() {
-		if err = mongoClient.Disconnect(ctx); err != nil {
-			panic(err)
+
+	serverOpts := interceptor.ServerOptions(logger)
+	var dialOpts []grpc.DialOption
+	if tlsCertFile != "" {
+		certs, err := tlsconfig.Load(tlsconfig.Config{
+			CertFile: tlsCertFile,
+			KeyFile:  tlsKeyFile,
+			CAFile:   tlsCAFile,
+			Mutual:   tlsMutual,
+		})
+		if err != nil {
+			logger.Fatal("failed to load certificates", zap.Error(err))
 		}
-	}()
+		defer certs.Close()
+
+		serverOpts = append(serverOpts, grpc.Creds(certs.ServerCredentials()))
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(certs.ClientCredentials()))
+	}
 
-	gRPCServer := grpc.NewServer()
+	gRPCServer := grpc.NewServer(serverOpts...)
 
 	l, err := net.Listen("tcp", gRPCAddr)
 	if err != nil {
 		logger.Fatal("failed to listen", zap.Error(err))
 	}
-	defer l.Close()
 
-	gateway := gateway.NewGateway(registry)
+	gateway, err := gateway.NewGateway(reg, dialOpts...)
+	if err != nil {
+		logger.Fatal("failed to create gateway", zap.Error(err))
+	}
 
 	store := NewStore(mongoClient)
-	service := NewService(store, gateway)
+	promotionStore := NewPromotionStore(mongoClient)
+	if err := promotionStore.EnsureIndexes(ctx); err != nil {
+		logger.Fatal("failed to create promotion indexes", zap.Error(err))
+	}
+
+	taxCalculator, err := newTaxCalculator()
+	if err != nil {
+		logger.Fatal("failed to configure tax", zap.Error(err))
+	}
+
+	service := NewService(store, promotionStore, taxCalculator, gateway)
 	serviceWithTelemetry := NewtelemetryMiddleware(service)
 	serviceWithLogging := NewloggingMiddleware(serviceWithTelemetry)
 
-	NewGRPCHandler(gRPCServer, serviceWithLogging, ch)
-
-	consumer := NewConsumer(serviceWithLogging)
-	go consumer.Listen(ch)
+	// the server's interceptors already log and trace gRPC calls
+	NewGRPCHandler(gRPCServer, service, amqpBroker)
+	NewPromotionGRPCHandler(gRPCServer, service)
+
+	consumer := NewConsumer(serviceWithLogging, broker.ConsumerConfig{
+		Queue:    broker.ServiceQueue(serviceName, broker.OrderPaidEvent),
+		Workers:  consumerWorkers,
+		Prefetch: consumerPrefetch,
+		Timeout:  time.Duration(consumerTimeout) * time.Millisecond,
+		Retry:    retryPolicy,
+	})
+
+	app := lifecycle.New(lifecycle.Config{
+		Registry: reg,
+		Instance: discovery.NewInstance(serviceName, gRPCAddr, "grpc"),
+	})
+	app.AddCheck("rabbitmq", amqpBroker.Ping)
+	app.AddCheck("mongo", func(ctx context.Context) error { return mongoClient.Ping(ctx, nil) })
+	app.AddGRPCServer(gRPCServer, l)
+	app.Go("consumer", func(ctx context.Context) error { return consumer.Listen(ctx, amqpBroker) })
+	app.AddCloser("gateway", func(context.Context) error { return gateway.Close() })
+	// in-flight messages have settled by now, so the broker can go before mongo
+	app.AddCloser("rabbitmq", func(context.Context) error { return amqpBroker.Close() })
+	app.AddCloser("mongo", mongoClient.Disconnect)
 
 	logger.Info("gRPC server has been started at %s", zap.String("port", gRPCAddr))
 
-	if err := gRPCServer.Serve(l); err != nil {
+	if err := app.Run(ctx); err != nil {
 		logger.Fatal("failed to serve gRPC server", zap.Error(err))
 	}
 }
+
+func newTaxCalculator() (tax.Calculator, error) {
+	switch taxProvider {
+	case "stripe":
+		return stripeTax.NewCalculator(), nil
+	case "table":
+		if stripeAutomaticTax {
+			return nil, errors.New("TAX_PROVIDER=table conflicts with STRIPE_AUTOMATIC_TAX=true, tax would be charged twice")
+		}
+		rates, err := tableTax.ParseRates(taxRates)
+		if err != nil {
+			return nil, err
+		}
+		defaultRate, err := strconv.ParseFloat(taxDefaultRate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TAX_DEFAULT_RATE: %w", err)
+		}
+		return tableTax.NewCalculator(rates, defaultRate), nil
+	default:
+		return nil, fmt.Errorf("unknown TAX_PROVIDER %q", taxProvider)
+	}
+}
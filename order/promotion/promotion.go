@@ -0,0 +1,187 @@
+package promotion
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/juxue97/common"
+	pb "github.com/juxue97/common/api"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	TypePercentage  = "percentage"
+	TypeFixedAmount = "fixed_amount"
+	TypeBuyXGetY    = "buy_x_get_y"
+)
+
+var (
+	ErrInvalidPromotion = errors.New("invalid promotion")
+	ErrDuplicateCode    = errors.New("discount code already exists")
+	ErrNotFound         = errors.New("discount code not found")
+	ErrInactive         = errors.New("discount code is not active")
+	ErrNotStarted       = errors.New("discount code is not valid yet")
+	ErrExpired          = errors.New("discount code has expired")
+	ErrUsageLimit       = errors.New("discount code usage limit reached")
+	ErrMinimumNotMet    = errors.New("order does not meet the minimum amount for this discount code")
+	ErrNotApplicable    = errors.New("discount code does not apply to this order")
+)
+
+type Promotion struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	Code           string             `bson:"code"`
+	Type           string             `bson:"type"`
+	PercentOff     float64            `bson:"percentOff,omitempty"`
+	AmountOff      int64              `bson:"amountOff,omitempty"`
+	Currency       string             `bson:"currency,omitempty"`
+	MinimumAmount  int64              `bson:"minimumAmount,omitempty"`
+	BuyItemID      string             `bson:"buyItemID,omitempty"`
+	BuyQuantity    int32              `bson:"buyQuantity,omitempty"`
+	GetQuantity    int32              `bson:"getQuantity,omitempty"`
+	StartsAt       time.Time          `bson:"startsAt,omitempty"`
+	EndsAt         time.Time          `bson:"endsAt,omitempty"`
+	MaxRedemptions int64              `bson:"maxRedemptions"`
+	Redemptions    int64              `bson:"redemptions"`
+	Active         bool               `bson:"active"`
+	CreatedAt      time.Time          `bson:"created_at,omitempty"`
+	UpdatedAt      time.Time          `bson:"updated_at,omitempty"`
+}
+
+// NormalizeCode makes codes case-insensitive.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func FromProto(p *pb.Promotion) *Promotion {
+	promo := &Promotion{
+		Code:           NormalizeCode(p.Code),
+		Type:           p.Type,
+		PercentOff:     p.PercentOff,
+		AmountOff:      p.AmountOff,
+		Currency:       p.Currency,
+		MinimumAmount:  p.MinimumAmount,
+		BuyItemID:      p.BuyItemID,
+		BuyQuantity:    p.BuyQuantity,
+		GetQuantity:    p.GetQuantity,
+		MaxRedemptions: p.MaxRedemptions,
+		Active:         p.Active,
+	}
+	if p.ID != "" {
+		promo.ID, _ = primitive.ObjectIDFromHex(p.ID)
+	}
+	if p.StartsAt != nil {
+		promo.StartsAt = p.StartsAt.AsTime()
+	}
+	if p.EndsAt != nil {
+		promo.EndsAt = p.EndsAt.AsTime()
+	}
+
+	return promo
+}
+
+func (p *Promotion) ToProto() *pb.Promotion {
+	promo := &pb.Promotion{
+		ID:             p.ID.Hex(),
+		Code:           p.Code,
+		Type:           p.Type,
+		PercentOff:     p.PercentOff,
+		AmountOff:      p.AmountOff,
+		Currency:       p.Currency,
+		MinimumAmount:  p.MinimumAmount,
+		BuyItemID:      p.BuyItemID,
+		BuyQuantity:    p.BuyQuantity,
+		GetQuantity:    p.GetQuantity,
+		MaxRedemptions: p.MaxRedemptions,
+		Redemptions:    p.Redemptions,
+		Active:         p.Active,
+	}
+	if !p.StartsAt.IsZero() {
+		promo.StartsAt = timestamppb.New(p.StartsAt)
+	}
+	if !p.EndsAt.IsZero() {
+		promo.EndsAt = timestamppb.New(p.EndsAt)
+	}
+
+	return promo
+}
+
+func (p *Promotion) Validate() error {
+	if p.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidPromotion)
+	}
+	if !p.StartsAt.IsZero() && !p.EndsAt.IsZero() && !p.EndsAt.After(p.StartsAt) {
+		return fmt.Errorf("%w: endsAt must be after startsAt", ErrInvalidPromotion)
+	}
+	if p.MaxRedemptions < 0 || p.MinimumAmount < 0 {
+		return fmt.Errorf("%w: limits cannot be negative", ErrInvalidPromotion)
+	}
+
+	switch p.Type {
+	case TypePercentage:
+		if p.PercentOff <= 0 || p.PercentOff > 100 {
+			return fmt.Errorf("%w: percentOff must be between 0 and 100", ErrInvalidPromotion)
+		}
+	case TypeFixedAmount:
+		if p.AmountOff <= 0 || p.Currency == "" {
+			return fmt.Errorf("%w: amountOff and currency are required", ErrInvalidPromotion)
+		}
+	case TypeBuyXGetY:
+		if p.BuyItemID == "" || p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return fmt.Errorf("%w: buyItemID, buyQuantity and getQuantity are required", ErrInvalidPromotion)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidPromotion, p.Type)
+	}
+
+	return nil
+}
+
+// Apply returns the discount the promotion grants on the priced items. It
+// checks the validity window and usage limit but does not redeem the code.
+func (p *Promotion) Apply(items []*pb.Item, currency string, subtotal int64, now time.Time) (int64, error) {
+	switch {
+	case !p.Active:
+		return 0, ErrInactive
+	case !p.StartsAt.IsZero() && now.Before(p.StartsAt):
+		return 0, ErrNotStarted
+	case !p.EndsAt.IsZero() && !now.Before(p.EndsAt):
+		return 0, ErrExpired
+	case p.MaxRedemptions > 0 && p.Redemptions >= p.MaxRedemptions:
+		return 0, ErrUsageLimit
+	case subtotal < p.MinimumAmount:
+		return 0, ErrMinimumNotMet
+	}
+
+	var discount int64
+	switch p.Type {
+	case TypePercentage:
+		discount = int64(math.Round(float64(subtotal) * p.PercentOff / 100))
+
+	case TypeFixedAmount:
+		if !common.SameCurrency(p.Currency, currency) {
+			return 0, ErrNotApplicable
+		}
+		discount = p.AmountOff
+
+	case TypeBuyXGetY:
+		for _, item := range items {
+			if item.ID != p.BuyItemID {
+				continue
+			}
+			bundles := int64(item.Quantity) / int64(p.BuyQuantity+p.GetQuantity)
+			discount += bundles * int64(p.GetQuantity) * item.UnitPrice
+		}
+		if discount == 0 {
+			return 0, ErrNotApplicable
+		}
+
+	default:
+		return 0, ErrNotApplicable
+	}
+
+	return min(discount, subtotal), nil
+}
@@ -0,0 +1,50 @@
+package promotion
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/juxue97/common/api"
+)
+
+func TestApply(t *testing.T) {
+	now := time.Now()
+	items := []*pb.Item{
+		{ID: "a", Quantity: 5, UnitPrice: 1000, Currency: "myr"},
+		{ID: "b", Quantity: 1, UnitPrice: 2500, Currency: "myr"},
+	}
+	const subtotal = 7500
+
+	tests := []struct {
+		name     string
+		promo    Promotion
+		discount int64
+		err      error
+	}{
+		{"percentage", Promotion{Type: TypePercentage, PercentOff: 10, Active: true}, 750, nil},
+		{"fixed amount", Promotion{Type: TypeFixedAmount, AmountOff: 500, Currency: "MYR", Active: true}, 500, nil},
+		{"fixed amount capped at subtotal", Promotion{Type: TypeFixedAmount, AmountOff: 9000, Currency: "myr", Active: true}, subtotal, nil},
+		{"fixed amount other currency", Promotion{Type: TypeFixedAmount, AmountOff: 500, Currency: "usd", Active: true}, 0, ErrNotApplicable},
+		{"buy 2 get 1", Promotion{Type: TypeBuyXGetY, BuyItemID: "a", BuyQuantity: 2, GetQuantity: 1, Active: true}, 1000, nil},
+		{"buy x get y without item", Promotion{Type: TypeBuyXGetY, BuyItemID: "c", BuyQuantity: 1, GetQuantity: 1, Active: true}, 0, ErrNotApplicable},
+		{"minimum basket met", Promotion{Type: TypePercentage, PercentOff: 50, MinimumAmount: subtotal, Active: true}, 3750, nil},
+		{"minimum basket not met", Promotion{Type: TypePercentage, PercentOff: 50, MinimumAmount: subtotal + 1, Active: true}, 0, ErrMinimumNotMet},
+		{"inactive", Promotion{Type: TypePercentage, PercentOff: 10}, 0, ErrInactive},
+		{"not started", Promotion{Type: TypePercentage, PercentOff: 10, StartsAt: now.Add(time.Hour), Active: true}, 0, ErrNotStarted},
+		{"expired", Promotion{Type: TypePercentage, PercentOff: 10, EndsAt: now.Add(-time.Hour), Active: true}, 0, ErrExpired},
+		{"usage limit", Promotion{Type: TypePercentage, PercentOff: 10, MaxRedemptions: 3, Redemptions: 3, Active: true}, 0, ErrUsageLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discount, err := tt.promo.Apply(items, "myr", subtotal, now)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Apply failed: Supposed to be %v, but got %v", tt.err, err)
+			}
+			if discount != tt.discount {
+				t.Errorf("Apply failed: Supposed to be %d, but got %d", tt.discount, discount)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/juxue97/common"
+	pb "github.com/juxue97/common/api"
+	"github.com/juxue97/order/promotion"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type promotionGRPCHandler struct {
+	pb.UnimplementedPromotionServiceServer
+	service OrderService
+}
+
+func NewPromotionGRPCHandler(gRPCServer *grpc.Server, service OrderService) {
+	handler := &promotionGRPCHandler{service: service}
+	pb.RegisterPromotionServiceServer(gRPCServer, handler)
+}
+
+func (h *promotionGRPCHandler) CreatePromotion(ctx context.Context, payload *pb.Promotion) (*pb.Promotion, error) {
+	p, err := h.service.createPromotion(ctx, payload)
+	return p, promotionStatus(err)
+}
+
+func (h *promotionGRPCHandler) GetPromotion(ctx context.Context, payload *pb.GetPromotionRequest) (*pb.Promotion, error) {
+	p, err := h.service.getPromotion(ctx, payload.ID)
+	return p, promotionStatus(err)
+}
+
+func (h *promotionGRPCHandler) ListPromotions(ctx context.Context, payload *pb.Empty) (*pb.ListPromotionsResponse, error) {
+	promotions, err := h.service.listPromotions(ctx)
+	if err != nil {
+		return nil, promotionStatus(err)
+	}
+
+	return &pb.ListPromotionsResponse{Promotions: promotions}, nil
+}
+
+func (h *promotionGRPCHandler) UpdatePromotion(ctx context.Context, payload *pb.Promotion) (*pb.Promotion, error) {
+	p, err := h.service.updatePromotion(ctx, payload)
+	return p, promotionStatus(err)
+}
+
+func (h *promotionGRPCHandler) DeletePromotion(ctx context.Context, payload *pb.GetPromotionRequest) (*pb.Empty, error) {
+	if err := h.service.deletePromotion(ctx, payload.ID); err != nil {
+		return nil, promotionStatus(err)
+	}
+
+	return &pb.Empty{}, nil
+}
+
+func promotionStatus(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, common.ErrNoDoc):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, promotion.ErrInvalidPromotion):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, promotion.ErrDuplicateCode):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/juxue97/common"
+	"github.com/juxue97/order/promotion"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const PromotionCollectionName = "promotions"
+
+type promotionStore struct {
+	mongoDB *mongo.Client
+}
+
+func NewPromotionStore(mongoDB *mongo.Client) *promotionStore {
+	return &promotionStore{mongoDB: mongoDB}
+}
+
+func (s *promotionStore) collection() *mongo.Collection {
+	return s.mongoDB.Database(DbName).Collection(PromotionCollectionName)
+}
+
+func (s *promotionStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (s *promotionStore) Create(ctx context.Context, p *promotion.Promotion) (primitive.ObjectID, error) {
+	p.ID = primitive.NilObjectID
+	p.Redemptions = 0
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = time.Now()
+
+	res, err := s.collection().InsertOne(ctx, p)
+	if mongo.IsDuplicateKeyError(err) {
+		return primitive.NilObjectID, promotion.ErrDuplicateCode
+	} else if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	id, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, common.ErrConvertID
+	}
+
+	return id, nil
+}
+
+func (s *promotionStore) Get(ctx context.Context, id string) (*promotion.Promotion, error) {
+	oID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findOne(ctx, bson.M{"_id": oID})
+}
+
+func (s *promotionStore) GetByCode(ctx context.Context, code string) (*promotion.Promotion, error) {
+	return s.findOne(ctx, bson.M{"code": promotion.NormalizeCode(code)})
+}
+
+func (s *promotionStore) findOne(ctx context.Context, filter bson.M) (*promotion.Promotion, error) {
+	var p promotion.Promotion
+	err := s.collection().FindOne(ctx, filter).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		return nil, common.ErrNoDoc
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to find promotion: %v", err)
+	}
+
+	return &p, nil
+}
+
+func (s *promotionStore) List(ctx context.Context) ([]*promotion.Promotion, error) {
+	cursor, err := s.collection().Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var promotions []*promotion.Promotion
+	if err := cursor.All(ctx, &promotions); err != nil {
+		return nil, fmt.Errorf("failed to decode promotions: %v", err)
+	}
+
+	return promotions, nil
+}
+
+func (s *promotionStore) Update(ctx context.Context, id string, p *promotion.Promotion) (*promotion.Promotion, error) {
+	oID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// redemptions are only ever changed through Redeem and Release
+	update := bson.M{"$set": bson.M{
+		"code":           p.Code,
+		"type":           p.Type,
+		"percentOff":     p.PercentOff,
+		"amountOff":      p.AmountOff,
+		"currency":       p.Currency,
+		"minimumAmount":  p.MinimumAmount,
+		"buyItemID":      p.BuyItemID,
+		"buyQuantity":    p.BuyQuantity,
+		"getQuantity":    p.GetQuantity,
+		"startsAt":       p.StartsAt,
+		"endsAt":         p.EndsAt,
+		"maxRedemptions": p.MaxRedemptions,
+		"active":         p.Active,
+		"updated_at":     time.Now(),
+	}}
+
+	var updated promotion.Promotion
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = s.collection().FindOneAndUpdate(ctx, bson.M{"_id": oID}, update, opts).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, common.ErrNoDoc
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, promotion.ErrDuplicateCode
+		}
+		return nil, fmt.Errorf("update failed: %v", err)
+	}
+
+	return &updated, nil
+}
+
+func (s *promotionStore) Delete(ctx context.Context, id string) error {
+	oID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.collection().DeleteOne(ctx, bson.M{"_id": oID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return common.ErrNoDoc
+	}
+
+	return nil
+}
+
+// Redeem atomically takes one use of the promotion, failing with
+// ErrUsageLimit once MaxRedemptions is reached.
+func (s *promotionStore) Redeem(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{
+		"_id":    id,
+		"active": true,
+		"$or": bson.A{
+			bson.M{"maxRedemptions": 0},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$redemptions", "$maxRedemptions"}}},
+		},
+	}
+	update := bson.M{
+		"$inc": bson.M{"redemptions": 1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	res, err := s.collection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return promotion.ErrUsageLimit
+	}
+
+	return nil
+}
+
+// Release gives back a use taken by Redeem, e.g. when the order that
+// redeemed it could not be stored.
+func (s *promotionStore) Release(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{
+		"_id":         id,
+		"redemptions": bson.M{"$gt": 0},
+	}
+	update := bson.M{
+		"$inc": bson.M{"redemptions": -1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	_, err := s.collection().UpdateOne(ctx, filter, update)
+	return err
+}
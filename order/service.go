@@ -2,43 +2,168 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"time"
 
+	"github.com/juxue97/common"
 	pb "github.com/juxue97/common/api"
 	"github.com/juxue97/order/gateway"
+	"github.com/juxue97/order/promotion"
+	"github.com/juxue97/order/tax"
 )
 
+var errInsufficientStock = errors.New("insufficient stock amount")
+
 type service struct {
-	store   OrderStore
-	gateway gateway.StocksGateway
+	store      OrderStore
+	promotions PromotionStore
+	tax        tax.Calculator
+	gateway    gateway.StocksGateway
 }
 
-func NewService(store OrderStore, gateway gateway.StocksGateway) *service {
+func NewService(store OrderStore, promotions PromotionStore, tax tax.Calculator, gateway gateway.StocksGateway) *service {
 	return &service{
-		store:   store,
-		gateway: gateway,
+		store:      store,
+		promotions: promotions,
+		tax:        tax,
+		gateway:    gateway,
 	}
 }
 
 func (s *service) createOrder(ctx context.Context, payload *pb.CreateOrderRequest, items []*pb.Item) (*pb.Order, error) {
+	totals, err := calculateTotals(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var promo *promotion.Promotion
+	if payload.DiscountCode != "" {
+		promo, err = s.applyPromotion(ctx, payload.DiscountCode, items, &totals)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.applyTax(ctx, items, &totals); err != nil {
+		return nil, err
+	}
+
+	if promo != nil {
+		if err := s.promotions.Redeem(ctx, promo.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	id, err := s.store.Create(ctx, Order{
-		CustomerID:  payload.CustomerID,
-		Status:      "pending",
-		Items:       items,
-		PaymentLink: "",
+		CustomerID:      payload.CustomerID,
+		Status:          "pending",
+		Items:           items,
+		PaymentLink:     "",
+		Currency:        totals.Currency,
+		Subtotal:        totals.Subtotal,
+		Tax:             totals.Tax,
+		Total:           totals.Total,
+		DiscountCode:    promoCode(promo),
+		Discount:        totals.Discount,
+		ShippingAddress: payload.ShippingAddress,
+		BillingAddress:  payload.BillingAddress,
+		ShippingMethod:  payload.ShippingMethod,
 	})
+	if err != nil && promo != nil {
+		if err := s.promotions.Release(ctx, promo.ID); err != nil {
+			log.Printf("failed to release promotion %s: %v", promo.Code, err)
+		}
+	}
 
 	o := &pb.Order{
-		ID:          id.Hex(),
-		CustomerID:  payload.CustomerID,
-		Status:      "pending",
-		Items:       items,
-		PaymentLink: "",
+		ID:              id.Hex(),
+		CustomerID:      payload.CustomerID,
+		Status:          "pending",
+		Items:           items,
+		PaymentLink:     "",
+		Currency:        totals.Currency,
+		Subtotal:        totals.Subtotal,
+		Tax:             totals.Tax,
+		Total:           totals.Total,
+		DiscountCode:    promoCode(promo),
+		Discount:        totals.Discount,
+		ShippingAddress: payload.ShippingAddress,
+		BillingAddress:  payload.BillingAddress,
+		ShippingMethod:  payload.ShippingMethod,
 	}
 
 	return o, err
 }
 
+// calculateTotals fills in the line totals of the priced items and sums them
+// up. All items of an order must be priced in the same currency.
+func calculateTotals(items []*pb.Item) (orderTotals, error) {
+	var totals orderTotals
+	for _, item := range items {
+		if totals.Currency == "" {
+			totals.Currency = item.Currency
+		} else if !common.SameCurrency(totals.Currency, item.Currency) {
+			return orderTotals{}, common.ErrMixedCurrency
+		}
+
+		item.LineTotal = item.UnitPrice * int64(item.Quantity)
+		totals.Subtotal += item.LineTotal
+	}
+	totals.Total = totals.Subtotal - totals.Discount + totals.Tax
+
+	return totals, nil
+}
+
+// applyPromotion looks up the discount code and takes its discount off the
+// totals. The code is not redeemed here.
+func (s *service) applyPromotion(ctx context.Context, code string, items []*pb.Item, totals *orderTotals) (*promotion.Promotion, error) {
+	promo, err := s.promotions.GetByCode(ctx, code)
+	if err == common.ErrNoDoc {
+		return nil, fmt.Errorf("%w: %s", promotion.ErrNotFound, code)
+	} else if err != nil {
+		return nil, err
+	}
+
+	discount, err := promo.Apply(items, totals.Currency, totals.Subtotal, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	totals.Discount = discount
+	totals.Total = totals.Subtotal - totals.Discount + totals.Tax
+
+	return promo, nil
+}
+
+// applyTax adds the tax due on the items, scaled down by the order discount
+// so that the discount is spread over all lines.
+func (s *service) applyTax(ctx context.Context, items []*pb.Item, totals *orderTotals) error {
+	tax, err := s.tax.Calculate(ctx, items)
+	if err != nil {
+		return err
+	}
+
+	if totals.Discount > 0 && totals.Subtotal > 0 {
+		taxable := float64(totals.Subtotal-totals.Discount) / float64(totals.Subtotal)
+		tax = int64(math.Round(float64(tax) * taxable))
+	}
+
+	totals.Tax = tax
+	totals.Total = totals.Subtotal - totals.Discount + totals.Tax
+
+	return nil
+}
+
+func promoCode(p *promotion.Promotion) string {
+	if p == nil {
+		return ""
+	}
+	return p.Code
+}
+
 func (s *service) getOrder(ctx context.Context, payload *pb.GetOrderRequest) (*pb.Order, error) {
 	o, err := s.store.Get(ctx, payload.OrderID, payload.CustomerID)
 	if err != nil {
@@ -65,7 +190,18 @@ func (s *service) validateOrder(ctx context.Context, payload *pb.CreateOrderRequ
 	}
 
 	if !inStock {
-		return nil, fmt.Errorf("insufficient stock amount")
+		return nil, errInsufficientStock
+	}
+
+	if payload.DiscountCode != "" {
+		totals, err := calculateTotals(itemsWithPrice)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := s.applyPromotion(ctx, payload.DiscountCode, itemsWithPrice, &totals); err != nil {
+			return nil, err
+		}
 	}
 
 	return itemsWithPrice, nil
@@ -98,3 +234,83 @@ func (s *service) updateOrder(ctx context.Context, o *pb.Order) (*pb.Order, erro
 
 	return o, nil
 }
+
+// cancelOrder marks an order that can never be paid as cancelled and gives
+// back its discount code. Orders already paid or cancelled are left alone, so
+// the code is released once however often the order is cancelled.
+func (s *service) cancelOrder(ctx context.Context, o *pb.Order) error {
+	cancelled, err := s.store.Cancel(ctx, o.ID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return nil
+	}
+	o.Status = "cancelled"
+
+	if o.DiscountCode == "" {
+		return nil
+	}
+	promo, err := s.promotions.GetByCode(ctx, o.DiscountCode)
+	if err != nil {
+		return fmt.Errorf("releasing discount code %s: %w", o.DiscountCode, err)
+	}
+
+	return s.promotions.Release(ctx, promo.ID)
+}
+
+func (s *service) createPromotion(ctx context.Context, p *pb.Promotion) (*pb.Promotion, error) {
+	promo := promotion.FromProto(p)
+	if err := promo.Validate(); err != nil {
+		return nil, err
+	}
+
+	id, err := s.promotions.Create(ctx, promo)
+	if err != nil {
+		return nil, err
+	}
+	promo.ID = id
+
+	return promo.ToProto(), nil
+}
+
+func (s *service) getPromotion(ctx context.Context, id string) (*pb.Promotion, error) {
+	promo, err := s.promotions.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return promo.ToProto(), nil
+}
+
+func (s *service) listPromotions(ctx context.Context) ([]*pb.Promotion, error) {
+	promos, err := s.promotions.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*pb.Promotion, 0, len(promos))
+	for _, promo := range promos {
+		res = append(res, promo.ToProto())
+	}
+
+	return res, nil
+}
+
+func (s *service) updatePromotion(ctx context.Context, p *pb.Promotion) (*pb.Promotion, error) {
+	promo := promotion.FromProto(p)
+	if err := promo.Validate(); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.promotions.Update(ctx, p.ID, promo)
+	if err != nil {
+		return nil, err
+	}
+
+	return updated.ToProto(), nil
+}
+
+func (s *service) deletePromotion(ctx context.Context, id string) error {
+	return s.promotions.Delete(ctx, id)
+}
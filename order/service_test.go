@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/juxue97/common/api"
+	"github.com/juxue97/order/promotion"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fixedTax int64
+
+func (t fixedTax) Calculate(ctx context.Context, items []*pb.Item) (int64, error) {
+	return int64(t), nil
+}
+
+func TestApplyTaxProratesDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		subtotal int64
+		discount int64
+		tax      int64
+		want     int64
+	}{
+		{"no discount", 10000, 0, 600, 600},
+		{"quarter off", 10000, 2500, 600, 450},
+		{"rounded", 3000, 1000, 100, 67},
+		{"fully discounted", 5000, 5000, 300, 0},
+	}
+	for _, tt := range tests {
+		s := &service{tax: fixedTax(tt.tax)}
+		totals := orderTotals{Subtotal: tt.subtotal, Discount: tt.discount}
+
+		if err := s.applyTax(context.Background(), nil, &totals); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if totals.Tax != tt.want {
+			t.Errorf("%s: expected tax %d, got %d", tt.name, tt.want, totals.Tax)
+		}
+		if want := tt.subtotal - tt.discount + tt.want; totals.Total != want {
+			t.Errorf("%s: expected total %d, got %d", tt.name, want, totals.Total)
+		}
+	}
+}
+
+type statusStore struct {
+	OrderStore
+	status map[string]string
+}
+
+func (s *statusStore) Cancel(ctx context.Context, id string) (bool, error) {
+	if status := s.status[id]; status != "pending" && status != "waiting_payment" {
+		return false, nil
+	}
+	s.status[id] = "cancelled"
+	return true, nil
+}
+
+type countingPromotions struct {
+	PromotionStore
+	redeemed, released int
+}
+
+func (p *countingPromotions) GetByCode(ctx context.Context, code string) (*promotion.Promotion, error) {
+	return &promotion.Promotion{Code: code, Type: promotion.TypePercentage, PercentOff: 10, Active: true}, nil
+}
+
+func (p *countingPromotions) Redeem(ctx context.Context, id primitive.ObjectID) error {
+	p.redeemed++
+	return nil
+}
+
+func (p *countingPromotions) Release(ctx context.Context, id primitive.ObjectID) error {
+	p.released++
+	return nil
+}
+
+func TestCancelOrderReleasesTheCodeOnce(t *testing.T) {
+	store := &statusStore{status: map[string]string{"unpaid": "waiting_payment", "paid": "paid"}}
+	promotions := &countingPromotions{}
+	s := &service{store: store, promotions: promotions}
+
+	// the expiry webhook may be delivered more than once
+	for i := 0; i < 2; i++ {
+		if err := s.cancelOrder(context.Background(), &pb.Order{ID: "unpaid", DiscountCode: "SAVE10"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.cancelOrder(context.Background(), &pb.Order{ID: "paid", DiscountCode: "SAVE10"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if store.status["unpaid"] != "cancelled" || store.status["paid"] != "paid" {
+		t.Errorf("expected only the unpaid order cancelled, got %v", store.status)
+	}
+	if promotions.released != 1 {
+		t.Errorf("expected the code released once, got %d", promotions.released)
+	}
+}
+
+type failingStore struct {
+	OrderStore
+}
+
+func (failingStore) Create(ctx context.Context, o Order) (primitive.ObjectID, error) {
+	return primitive.NilObjectID, errors.New("insert failed")
+}
+
+func TestCreateOrderReleasesTheCodeWhenTheInsertFails(t *testing.T) {
+	promotions := &countingPromotions{}
+	s := &service{store: failingStore{}, promotions: promotions, tax: fixedTax(0)}
+
+	payload := &pb.CreateOrderRequest{CustomerID: "c1", DiscountCode: "SAVE10"}
+	items := []*pb.Item{{ID: "i1", Quantity: 1, UnitPrice: 1000, Currency: "usd"}}
+	if _, err := s.createOrder(context.Background(), payload, items); err == nil {
+		t.Fatal("expected the insert error")
+	}
+
+	if promotions.redeemed != 1 || promotions.released != 1 {
+		t.Errorf("expected the code redeemed and released, got %d redeemed and %d released", promotions.redeemed, promotions.released)
+	}
+}
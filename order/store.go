@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	pb "github.com/juxue97/common/api"
@@ -15,6 +16,10 @@ const (
 	CollectionName = "orders"
 )
 
+// errOrderClosed is returned for updates to an order that was already
+// cancelled or paid, such as a payment link arriving for a cancelled order.
+var errOrderClosed = errors.New("order is cancelled or paid")
+
 type store struct {
 	mongoDB *mongo.Client
 }
@@ -27,12 +32,16 @@ func (s *store) Create(ctx context.Context, o Order) (primitive.ObjectID, error)
 	col := s.mongoDB.Database(DbName).Collection(CollectionName)
 
 	newOrder, err := col.InsertOne(ctx, o)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
 	id, ok := newOrder.InsertedID.(primitive.ObjectID)
 	if !ok {
 		return primitive.NilObjectID, fmt.Errorf("failed to convert inserted ID to primitive.ObjectID")
 	}
 
-	return id, err
+	return id, nil
 }
 
 func (s *store) Get(ctx context.Context, orderID string, customerID string) (*Order, error) {
@@ -60,20 +69,56 @@ func (s *store) Update(ctx context.Context, id string, o *pb.Order) error {
 		return err
 	}
 
+	// a cancelled or paid order only takes an update that leaves its status
+	// as it is, so a late payment link can't reopen a cancelled order while a
+	// redelivered payment still succeeds
 	filter := bson.M{
 		"_id": oID,
+		"$or": bson.A{
+			bson.M{"status": bson.M{"$nin": bson.A{"cancelled", "paid"}}},
+			bson.M{"status": o.Status},
+		},
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"paymentLink": o.PaymentLink,
-			"status":      o.Status,
-		},
+	set := bson.M{
+		"paymentLink": o.PaymentLink,
+		"status":      o.Status,
 	}
 
+	// the address Stripe collected at checkout replaces the one given at order time
+	if o.ShippingAddress != nil {
+		set["shippingAddress"] = o.ShippingAddress
+		set["shipping"] = o.Shipping
+	}
+
+	update := bson.M{"$set": set}
+
 	result, err := col.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("no document found with id %s", id)
+		return fmt.Errorf("updating order %s: %w", id, errOrderClosed)
+	}
+	return nil
+}
+
+func (s *store) Cancel(ctx context.Context, id string) (bool, error) {
+	col := s.mongoDB.Database(DbName).Collection(CollectionName)
+	oID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	filter := bson.M{
+		"_id":    oID,
+		"status": bson.M{"$in": bson.A{"pending", "waiting_payment"}},
+	}
+	update := bson.M{"$set": bson.M{"status": "cancelled"}}
+
+	result, err := col.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
 	}
-	return err
+	return result.ModifiedCount > 0, nil
 }
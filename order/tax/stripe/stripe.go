@@ -0,0 +1,19 @@
+package stripe
+
+import (
+	"context"
+
+	pb "github.com/juxue97/common/api"
+)
+
+// Stripe leaves tax at zero on the order: it is calculated by Stripe Tax at
+// checkout from the product tax codes and the customer's address.
+type Stripe struct{}
+
+func NewCalculator() *Stripe {
+	return &Stripe{}
+}
+
+func (s *Stripe) Calculate(ctx context.Context, items []*pb.Item) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,60 @@
+package table
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	pb "github.com/juxue97/common/api"
+)
+
+// Table applies a fixed rate per tax code, for environments without Stripe
+// Tax. Items with a code missing from the table use the default rate.
+type Table struct {
+	rates       map[string]float64
+	defaultRate float64
+}
+
+func NewCalculator(rates map[string]float64, defaultRate float64) *Table {
+	return &Table{rates: rates, defaultRate: defaultRate}
+}
+
+// ParseRates reads a "taxCode=rate" comma separated list, e.g.
+// "txcd_99999999=0.06,txcd_00000000=0".
+func ParseRates(s string) (map[string]float64, error) {
+	rates := map[string]float64{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		code, rate, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tax rate %q, expected code=rate", entry)
+		}
+
+		r, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || r < 0 {
+			return nil, fmt.Errorf("invalid tax rate %q", entry)
+		}
+		rates[strings.TrimSpace(code)] = r
+	}
+
+	return rates, nil
+}
+
+func (t *Table) Calculate(ctx context.Context, items []*pb.Item) (int64, error) {
+	var tax int64
+	for _, item := range items {
+		rate, ok := t.rates[item.TaxCode]
+		if !ok {
+			rate = t.defaultRate
+		}
+		tax += int64(math.Round(float64(item.LineTotal) * rate))
+	}
+
+	return tax, nil
+}
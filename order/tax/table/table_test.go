@@ -0,0 +1,46 @@
+package table
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/juxue97/common/api"
+)
+
+func TestParseRates(t *testing.T) {
+	rates, err := ParseRates(" txcd_99999999=0.06, txcd_00000000 = 0 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 || rates["txcd_99999999"] != 0.06 || rates["txcd_00000000"] != 0 {
+		t.Errorf("unexpected rates %v", rates)
+	}
+
+	if rates, err := ParseRates(""); err != nil || len(rates) != 0 {
+		t.Errorf("expected no rates, got %v, %v", rates, err)
+	}
+
+	for _, s := range []string{"txcd_99999999", "txcd_99999999=six", "txcd_99999999=-0.06"} {
+		if _, err := ParseRates(s); err == nil {
+			t.Errorf("expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	table := NewCalculator(map[string]float64{"food": 0, "service": 0.08}, 0.06)
+	items := []*pb.Item{
+		{TaxCode: "food", LineTotal: 5000},
+		{TaxCode: "service", LineTotal: 1250},
+		// no code, so the default rate, rounded per line
+		{LineTotal: 999},
+	}
+
+	tax, err := table.Calculate(context.Background(), items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(0 + 100 + 60); tax != want {
+		t.Errorf("expected tax %d, got %d", want, tax)
+	}
+}
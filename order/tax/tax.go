@@ -0,0 +1,13 @@
+package tax
+
+import (
+	"context"
+
+	pb "github.com/juxue97/common/api"
+)
+
+// Calculator works out the tax due on priced order items, in the smallest
+// currency unit, before any order level discount.
+type Calculator interface {
+	Calculate(ctx context.Context, items []*pb.Item) (int64, error)
+}
@@ -48,3 +48,39 @@ func (s *telemetryMiddleware) updateOrder(ctx context.Context, o *pb.Order) (*pb
 	span.AddEvent(fmt.Sprintf("UpdateOrder: %v", o))
 	return s.next.updateOrder(ctx, o)
 }
+
+func (s *telemetryMiddleware) cancelOrder(ctx context.Context, o *pb.Order) error {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent(fmt.Sprintf("CancelOrder: %v", o.ID))
+	return s.next.cancelOrder(ctx, o)
+}
+
+func (s *telemetryMiddleware) createPromotion(ctx context.Context, p *pb.Promotion) (*pb.Promotion, error) {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent(fmt.Sprintf("CreatePromotion: %v", p))
+	return s.next.createPromotion(ctx, p)
+}
+
+func (s *telemetryMiddleware) getPromotion(ctx context.Context, id string) (*pb.Promotion, error) {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent(fmt.Sprintf("GetPromotion: %v", id))
+	return s.next.getPromotion(ctx, id)
+}
+
+func (s *telemetryMiddleware) listPromotions(ctx context.Context) ([]*pb.Promotion, error) {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent("ListPromotions")
+	return s.next.listPromotions(ctx)
+}
+
+func (s *telemetryMiddleware) updatePromotion(ctx context.Context, p *pb.Promotion) (*pb.Promotion, error) {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent(fmt.Sprintf("UpdatePromotion: %v", p))
+	return s.next.updatePromotion(ctx, p)
+}
+
+func (s *telemetryMiddleware) deletePromotion(ctx context.Context, id string) error {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent(fmt.Sprintf("DeletePromotion: %v", id))
+	return s.next.deletePromotion(ctx, id)
+}
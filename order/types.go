@@ -4,6 +4,7 @@ import (
 	"context"
 
 	pb "github.com/juxue97/common/api"
+	"github.com/juxue97/order/promotion"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -12,29 +13,79 @@ type OrderService interface {
 	validateOrder(context.Context, *pb.CreateOrderRequest) ([]*pb.Item, error)
 	getOrder(context.Context, *pb.GetOrderRequest) (*pb.Order, error)
 	updateOrder(context.Context, *pb.Order) (*pb.Order, error)
+	cancelOrder(context.Context, *pb.Order) error
 	getOrderForStock(ctx context.Context, payload *pb.GetOrderRequest) (*pb.Order, error)
+
+	createPromotion(context.Context, *pb.Promotion) (*pb.Promotion, error)
+	getPromotion(context.Context, string) (*pb.Promotion, error)
+	listPromotions(context.Context) ([]*pb.Promotion, error)
+	updatePromotion(context.Context, *pb.Promotion) (*pb.Promotion, error)
+	deletePromotion(context.Context, string) error
 }
 
 type OrderStore interface {
 	Create(context.Context, Order) (primitive.ObjectID, error)
 	Get(context.Context, string, string) (*Order, error)
 	Update(context.Context, string, *pb.Order) error
+	// Cancel cancels an order still waiting for payment, reporting whether it
+	// did so, so that only one caller acts on the cancellation.
+	Cancel(context.Context, string) (bool, error)
+}
+
+type PromotionStore interface {
+	Create(context.Context, *promotion.Promotion) (primitive.ObjectID, error)
+	Get(context.Context, string) (*promotion.Promotion, error)
+	GetByCode(context.Context, string) (*promotion.Promotion, error)
+	List(context.Context) ([]*promotion.Promotion, error)
+	Update(context.Context, string, *promotion.Promotion) (*promotion.Promotion, error)
+	Delete(context.Context, string) error
+	Redeem(context.Context, primitive.ObjectID) error
+	Release(context.Context, primitive.ObjectID) error
 }
 
 type Order struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty"`
-	CustomerID  string             `bson:"customerID,omitempty"`
-	Status      string             `bson:"status,omitempty"`
-	Items       []*pb.Item         `bson:"items,omitempty"`
-	PaymentLink string             `bson:"paymentLink,omitempty"`
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	CustomerID      string             `bson:"customerID,omitempty"`
+	Status          string             `bson:"status,omitempty"`
+	Items           []*pb.Item         `bson:"items,omitempty"`
+	PaymentLink     string             `bson:"paymentLink,omitempty"`
+	Currency        string             `bson:"currency,omitempty"`
+	Subtotal        int64              `bson:"subtotal"`
+	Tax             int64              `bson:"tax"`
+	Total           int64              `bson:"total"`
+	DiscountCode    string             `bson:"discountCode,omitempty"`
+	Discount        int64              `bson:"discount"`
+	ShippingAddress *pb.Address        `bson:"shippingAddress,omitempty"`
+	BillingAddress  *pb.Address        `bson:"billingAddress,omitempty"`
+	ShippingMethod  string             `bson:"shippingMethod,omitempty"`
+	Shipping        int64              `bson:"shipping"`
+}
+
+type orderTotals struct {
+	Currency string
+	Subtotal int64
+	Discount int64
+	Tax      int64
+	Total    int64
 }
 
 func (o *Order) ToProto() *pb.Order {
 	return &pb.Order{
-		ID:          o.ID.Hex(),
-		CustomerID:  o.CustomerID,
-		Status:      o.Status,
-		PaymentLink: o.PaymentLink,
+		ID:              o.ID.Hex(),
+		CustomerID:      o.CustomerID,
+		Status:          o.Status,
+		Items:           o.Items,
+		PaymentLink:     o.PaymentLink,
+		Currency:        o.Currency,
+		Subtotal:        o.Subtotal,
+		Tax:             o.Tax,
+		Total:           o.Total,
+		DiscountCode:    o.DiscountCode,
+		Discount:        o.Discount,
+		ShippingAddress: o.ShippingAddress,
+		BillingAddress:  o.BillingAddress,
+		ShippingMethod:  o.ShippingMethod,
+		Shipping:        o.Shipping,
 	}
 }
 
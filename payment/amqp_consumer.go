@@ -2,66 +2,62 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 
 	pb "github.com/juxue97/common/api"
 	"github.com/juxue97/common/broker"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type consumer struct {
 	service *loggingMiddleware
+	config  broker.ConsumerConfig
 }
 
-func NewConsumer(service *loggingMiddleware) *consumer {
-	return &consumer{service: service}
+func NewConsumer(service *loggingMiddleware, config broker.ConsumerConfig) *consumer {
+	return &consumer{service: service, config: config}
 }
 
-func (c *consumer) Listen(ch *amqp.Channel) {
-	q, err := ch.QueueDeclare(broker.OrderCreatedEvent, true, false, false, false, nil)
+// Listen consumes order.created until ctx is done.
+// The queue is declared by broker.PaymentTopology.
+func (c *consumer) Listen(ctx context.Context, b broker.Broker) error {
+	runner, err := broker.NewConsumer(b, c.config, c.handle)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	messages, err := ch.Consume(q.Name, "", false, false, false, false, nil)
+	return runner.Run(ctx)
+}
+
+func (c *consumer) handle(ctx context.Context, d broker.Delivery) error {
+	o := &pb.Order{}
+	event, err := broker.Events.Decode(d, broker.OrderCreatedEvent, o)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("failed to decode event: %v", err)
+		return broker.Permanent(err)
 	}
 
-	var forever chan struct{}
-
-	go func() {
-		for d := range messages {
-			o := &pb.Order{}
-			if err := json.Unmarshal(d.Body, o); err != nil {
-				d.Nack(false, false)
-				log.Printf("failed to unmarshal order: %v", err)
-				continue
-			}
-
-			ctx := broker.ExtractAMQPHeaders(context.Background(), d.Headers)
-			tr := otel.Tracer("amqp")
-			_, messageSpan := tr.Start(ctx, fmt.Sprintf(
-				"AMQP - consume - %s", q.Name,
-			))
-			paymentLink, err := c.service.CreatePayment(ctx, o)
-			if err != nil {
-				log.Printf("failed to create payment: %v", err)
-				if err := broker.HandleRetry(ch, &d); err != nil {
-					log.Printf("failed to handle retry: %v", err)
-				}
+	ctx = broker.ExtractAMQPHeaders(ctx, d.Headers)
+	ctx = broker.WithCorrelationID(ctx, event.CorrelationID)
+	tr := otel.Tracer("amqp")
+	_, messageSpan := tr.Start(ctx, fmt.Sprintf(
+		"AMQP - consume - %s", c.config.Queue,
+	))
+	defer messageSpan.End()
 
-				continue
-			}
-			messageSpan.AddEvent(fmt.Sprintf("payment.created: %s", paymentLink))
-			messageSpan.End()
-
-			d.Ack(false)
+	paymentLink, err := c.service.CreatePayment(ctx, o)
+	if err != nil {
+		log.Printf("failed to create payment: %v", err)
+		// the order was cancelled meanwhile and won't take a payment link
+		if status.Code(err) == codes.FailedPrecondition {
+			return broker.Permanent(err)
 		}
-	}()
+		return err
+	}
+	messageSpan.AddEvent(fmt.Sprintf("payment.created: %s", paymentLink))
 
-	<-forever
+	return nil
 }
@@ -1,7 +1,14 @@
 package gateway
 
-import "context"
+import (
+	"context"
+
+	pb "github.com/juxue97/common/api"
+)
 
 type OrdersGateway interface {
 	UpdateOrderAfterPaymentLink(ctx context.Context, orderID, paymentLink string) error
+	GetOrder(ctx context.Context, orderID, customerID string) (*pb.Order, error)
+	UpdateOrder(ctx context.Context, o *pb.Order) error
+	CancelOrder(ctx context.Context, orderID, customerID string) error
 }
@@ -2,35 +2,59 @@ package gateway
 
 import (
 	"context"
-	"log"
 
 	pb "github.com/juxue97/common/api"
 	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/resilience"
+	"google.golang.org/grpc"
 )
 
 var orderServiceName = "orders"
 
 type gateway struct {
-	registry discovery.Registry
+	conn   *grpc.ClientConn
+	client pb.OrderServiceClient
 }
 
-func NewGateway(registry discovery.Registry) *gateway {
-	return &gateway{registry: registry}
-}
-
-func (g *gateway) UpdateOrderAfterPaymentLink(ctx context.Context, orderID, paymentLink string) error {
-	conn, err := discovery.ServiceConnection(context.Background(), "orders", g.registry)
+func NewGateway(registry discovery.Registry, opts ...grpc.DialOption) (*gateway, error) {
+	breaker := resilience.NewBreaker(orderServiceName, resilience.BreakerConfig{})
+	conn, err := discovery.NewClient(orderServiceName, registry, append(resilience.DialOptions(breaker, resilience.OrderServiceMethods...), opts...)...)
 	if err != nil {
-		log.Fatalf("Failed to dial server: %v", err)
+		return nil, err
 	}
-	defer conn.Close()
 
-	ordersClient := pb.NewOrderServiceClient(conn)
+	return &gateway{conn: conn, client: pb.NewOrderServiceClient(conn)}, nil
+}
 
-	_, err = ordersClient.UpdateOrder(ctx, &pb.Order{
+func (g *gateway) Close() error {
+	return g.conn.Close()
+}
+
+func (g *gateway) UpdateOrderAfterPaymentLink(ctx context.Context, orderID, paymentLink string) error {
+	_, err := g.client.UpdateOrder(ctx, &pb.Order{
 		ID:          orderID,
 		Status:      "waiting_payment",
 		PaymentLink: paymentLink,
 	})
 	return err
 }
+
+func (g *gateway) GetOrder(ctx context.Context, orderID, customerID string) (*pb.Order, error) {
+	return g.client.GetOrder(ctx, &pb.GetOrderRequest{
+		OrderID:    orderID,
+		CustomerID: customerID,
+	})
+}
+
+func (g *gateway) UpdateOrder(ctx context.Context, o *pb.Order) error {
+	_, err := g.client.UpdateOrder(ctx, o)
+	return err
+}
+
+func (g *gateway) CancelOrder(ctx context.Context, orderID, customerID string) error {
+	_, err := g.client.CancelOrder(ctx, &pb.GetOrderRequest{
+		OrderID:    orderID,
+		CustomerID: customerID,
+	})
+	return err
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,20 +11,21 @@ import (
 	"os"
 	"time"
 
-	pb "github.com/juxue97/common/api"
+	"github.com/juxue97/common"
 	"github.com/juxue97/common/broker"
-	amqp "github.com/rabbitmq/amqp091-go"
+	stripeProcessor "github.com/juxue97/payment/processor/stripe"
 	"github.com/stripe/stripe-go/v81"
 	"github.com/stripe/stripe-go/v81/webhook"
 	"go.opentelemetry.io/otel"
 )
 
 type PaymentHTTPHandler struct {
-	channel *amqp.Channel
+	publisher broker.Publisher
+	service   *loggingMiddleware
 }
 
-func NewPaymentHTTPHandler(channel *amqp.Channel) *PaymentHTTPHandler {
-	return &PaymentHTTPHandler{channel: channel}
+func NewPaymentHTTPHandler(publisher broker.Publisher, service *loggingMiddleware) *PaymentHTTPHandler {
+	return &PaymentHTTPHandler{publisher: publisher, service: service}
 }
 
 func (h *PaymentHTTPHandler) registerRouters(router *http.ServeMux) {
@@ -75,16 +77,31 @@ func (h *PaymentHTTPHandler) handlerCheckoutWebhook(w http.ResponseWriter, r *ht
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 
-			o := &pb.Order{
-				Status:      "paid",
-				PaymentLink: "",
-				ID:          orderID,
-				CustomerID:  customerID,
+			// tax added by Stripe Tax and shipping are not part of the order total
+			amount := checkoutSession.AmountTotal
+			var shipping int64
+			if checkoutSession.TotalDetails != nil {
+				shipping = checkoutSession.TotalDetails.AmountShipping
+				amount -= checkoutSession.TotalDetails.AmountTax + shipping
 			}
 
-			marshalledOrder, err := json.Marshal(o)
+			_, err := h.service.VerifyPayment(ctx, orderID, customerID, amount, string(checkoutSession.Currency))
 			if err != nil {
-				log.Fatal(err)
+				if errors.Is(err, common.ErrAmountMismatch) {
+					// acknowledged to Stripe, the order is flagged for review instead of paid
+					log.Printf("Payment for checkout session %s rejected: %v", checkoutSession.ID, err)
+					return
+				}
+				fmt.Fprintf(os.Stderr, "Error verifying payment: %v\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			paid := broker.OrderPaid{
+				OrderID:         orderID,
+				CustomerID:      customerID,
+				ShippingAddress: stripeProcessor.ShippingAddress(checkoutSession.ShippingDetails),
+				Shipping:        shipping,
 			}
 
 			tr := otel.Tracer("amqp")
@@ -93,17 +110,51 @@ func (h *PaymentHTTPHandler) handlerCheckoutWebhook(w http.ResponseWriter, r *ht
 			))
 			defer messageSpan.End()
 
-			headers := broker.InjectAMQPHeaders(amqpContext)
+			event, err := broker.Events.NewEvent(amqpContext, serviceName, broker.OrderPaidEvent, paid)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error building event: %v\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
 
-			h.channel.PublishWithContext(amqpContext, broker.OrderPaidEvent, "", false, false, amqp.Publishing{
-				ContentType:  "application/json",
-				Headers:      headers,
-				Body:         marshalledOrder,
-				DeliveryMode: amqp.Persistent,
-			})
+			msg, err := broker.Events.Encode(amqpContext, event)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding event: %v\n", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			// a non-2xx response makes Stripe redeliver the webhook later
+			if err := h.publisher.Publish(amqpContext, broker.OrderPaidEvent, "", msg); err != nil {
+				fmt.Fprintf(os.Stderr, "Error publishing %s: %v\n", broker.OrderPaidEvent, err)
+				messageSpan.RecordError(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
 			// log.Println("event published: order paid")
 		}
 
+	case "checkout.session.expired":
+		var checkoutSession stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &checkoutSession); err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing webhook JSON: %v\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// the order can no longer be paid, so its discount code is released
+		orderID := checkoutSession.Metadata["orderID"]
+		customerID := checkoutSession.Metadata["customerID"]
+		if err := h.service.ExpirePayment(ctx, orderID, customerID); err != nil {
+			fmt.Fprintf(os.Stderr, "Error cancelling order %s: %v\n", orderID, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Checkout session %s expired, order %s cancelled.", checkoutSession.ID, orderID)
+
 	case "mandate.updated":
 		// log.Println("mandate updated")
 
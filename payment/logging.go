@@ -24,3 +24,21 @@ func (s *loggingMiddleware) CreatePayment(ctx context.Context, o *pb.Order) (str
 
 	return s.next.CreatePayment(ctx, o)
 }
+
+func (s *loggingMiddleware) VerifyPayment(ctx context.Context, orderID, customerID string, amount int64, currency string) (*pb.Order, error) {
+	start := time.Now()
+	defer func() {
+		zap.L().Info("VerifyPayment", zap.Duration("took", time.Since(start)))
+	}()
+
+	return s.next.VerifyPayment(ctx, orderID, customerID, amount, currency)
+}
+
+func (s *loggingMiddleware) ExpirePayment(ctx context.Context, orderID, customerID string) error {
+	start := time.Now()
+	defer func() {
+		zap.L().Info("ExpirePayment", zap.Duration("took", time.Since(start)))
+	}()
+
+	return s.next.ExpirePayment(ctx, orderID, customerID)
+}
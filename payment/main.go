@@ -10,10 +10,13 @@ import (
 	"github.com/juxue97/common"
 	"github.com/juxue97/common/broker"
 	"github.com/juxue97/common/discovery"
-	"github.com/juxue97/common/discovery/consul"
+	"github.com/juxue97/common/discovery/registry"
+	"github.com/juxue97/common/interceptor"
+	"github.com/juxue97/common/lifecycle"
+	"github.com/juxue97/common/resilience"
+	"github.com/juxue97/common/tlsconfig"
 	"github.com/juxue97/payment/gateway"
 	stripeProcessor "github.com/juxue97/payment/processor/stripe"
-	"github.com/stripe/stripe-go/v81"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
@@ -25,13 +28,41 @@ var (
 
 	jaegerAddr           = common.GetString("JAEGER_ADDR", "localhost:4318")
 	gRPCAddr             = common.GetString("GRPC_ADDR", "localhost:8002")
-	consulAddr           = common.GetString("CONSUL_ADDR", "localhost:8500")
 	amqpUser             = common.GetString("RABBITMQ_USER", "juxue")
 	amqpPass             = common.GetString("RABBITMQ_PASS", "veryStrongPassword")
 	amqpHost             = common.GetString("RABBITMQ_HOST", "localhost")
 	amqpPort             = common.GetString("RABBITMQ_PORT", "5672")
 	stripeKey            = common.GetString("STRIPE_KEY", "")
 	endpointStripeSecret = common.GetString("ENDPOINT_STRIPE_SECRET", "whsec_...")
+
+	tlsCertFile = common.GetString("TLS_CERT_FILE", "")
+	tlsKeyFile  = common.GetString("TLS_KEY_FILE", "")
+	tlsCAFile   = common.GetString("TLS_CA_FILE", "")
+	tlsMutual   = common.GetBool("TLS_MUTUAL", false)
+
+	retryMaxAttempts = common.GetInt("RETRY_MAX_ATTEMPTS", 3)
+	retryBaseDelay   = common.GetInt("RETRY_BASE_DELAY_MS", 1000)
+	retryMaxDelay    = common.GetInt("RETRY_MAX_DELAY_MS", 60000)
+
+	consumerWorkers  = common.GetInt("CONSUMER_WORKERS", 4)
+	consumerPrefetch = common.GetInt("CONSUMER_PREFETCH", 16)
+	consumerTimeout  = common.GetInt("CONSUMER_TIMEOUT_MS", 30000)
+
+	publishConfirmTimeout = common.GetInt("PUBLISH_CONFIRM_TIMEOUT_MS", 5000)
+
+	// "envelope", "cloudevents-binary" or "cloudevents-structured"
+	eventEncoding = common.GetString("EVENT_ENCODING", "envelope")
+
+	// the DLQ admin API is only served when a token is set
+	dlqAdminToken = common.GetString("DLQ_ADMIN_TOKEN", "")
+
+	stripeTimeout      = common.GetInt("STRIPE_TIMEOUT_MS", 10000)
+	stripeMaxAttempts  = common.GetInt("STRIPE_MAX_ATTEMPTS", 3)
+	stripeRetryBackoff = common.GetInt("STRIPE_RETRY_BACKOFF_MS", 200)
+	stripeAutomaticTax = common.GetBool("STRIPE_AUTOMATIC_TAX", false)
+	// e.g. "standard=shr_123,express=shr_456" and "MY,SG"
+	stripeShippingRates     = common.GetString("STRIPE_SHIPPING_RATES", "")
+	stripeShippingCountries = common.GetString("STRIPE_SHIPPING_COUNTRIES", "")
 )
 
 func main() {
@@ -48,67 +79,109 @@ func main() {
 		logger.Fatal("failed to set global tracer", zap.Error(err))
 	}
 
-	registry, err := consul.NewRegistry(consulAddr, serviceName)
+	reg, err := registry.FromEnv(serviceName)
 	if err != nil {
 		panic(err)
 	}
 
 	ctx := context.Background()
-	instanceID := discovery.GenerateInstanceID(serviceName)
-	if err := registry.Register(ctx, instanceID, serviceName, gRPCAddr); err != nil {
-		panic(err)
+
+	amqpBroker, err := broker.Connect(ctx, amqpUser, amqpPass, amqpHost, amqpPort)
+	if err != nil {
+		logger.Fatal("failed to connect to rabbitmq", zap.Error(err))
 	}
+	amqpBroker.SetConfirmTimeout(time.Duration(publishConfirmTimeout) * time.Millisecond)
 
-	go func() {
-		for {
-			if err := registry.HealthCheck(instanceID, serviceName); err != nil {
-				logger.Error("failed to health check", zap.Error(err))
-			}
-			time.Sleep(time.Second * 1)
-		}
-	}()
-	defer registry.Deregister(ctx, instanceID, serviceName)
+	retryPolicy := broker.RetryPolicy{
+		MaxAttempts: retryMaxAttempts,
+		BaseDelay:   time.Duration(retryBaseDelay) * time.Millisecond,
+		MaxDelay:    time.Duration(retryMaxDelay) * time.Millisecond,
+	}
+	if err := broker.PaymentTopology(retryPolicy).Apply(amqpBroker); err != nil {
+		logger.Fatal("failed to declare broker topology", zap.Error(err))
+	}
 
-	ch, close := broker.Connect(amqpUser, amqpPass, amqpHost, amqpPort)
-	defer func() {
-		close()
-		ch.Close()
-	}()
+	if err := broker.Events.SetEncoding(broker.Encoding(eventEncoding)); err != nil {
+		logger.Fatal("failed to configure event encoding", zap.Error(err))
+	}
 
 	// stripe conn
-	stripe.Key = stripeKey
+	common.SetupStripe(stripeKey)
 
-	// HTTPServer
-	mux := http.NewServeMux()
-	httpServer := NewPaymentHTTPHandler(ch)
-	httpServer.registerRouters(mux)
-
-	go func() {
-		logger.Info("starting http server", zap.String("port", httpAddr))
-		if err := http.ListenAndServe(httpAddr, mux); err != nil {
-			logger.Fatal("failed to start http server", zap.Error(err))
+	// grpcServer
+	serverOpts := interceptor.ServerOptions(logger)
+	var dialOpts []grpc.DialOption
+	if tlsCertFile != "" {
+		certs, err := tlsconfig.Load(tlsconfig.Config{
+			CertFile: tlsCertFile,
+			KeyFile:  tlsKeyFile,
+			CAFile:   tlsCAFile,
+			Mutual:   tlsMutual,
+		})
+		if err != nil {
+			logger.Fatal("failed to load certificates", zap.Error(err))
 		}
-	}()
+		defer certs.Close()
 
-	// grpcServer
-	gRPCServer := grpc.NewServer()
+		serverOpts = append(serverOpts, grpc.Creds(certs.ServerCredentials()))
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(certs.ClientCredentials()))
+	}
+
+	gRPCServer := grpc.NewServer(serverOpts...)
 
 	l, err := net.Listen("tcp", gRPCAddr)
 	if err != nil {
 		logger.Fatal("failed to listen", zap.Error(err))
 	}
-	defer l.Close()
 
-	stripeProcessor := stripeProcessor.NewProcessor()
-	gateway := gateway.NewGateway(registry)
+	stripeConfig := stripeProcessor.DefaultConfig()
+	stripeConfig.Timeout = time.Duration(stripeTimeout) * time.Millisecond
+	stripeConfig.Retry.MaxAttempts = stripeMaxAttempts
+	stripeConfig.Retry.BaseDelay = time.Duration(stripeRetryBackoff) * time.Millisecond
+	stripeConfig.AutomaticTax = stripeAutomaticTax
+	stripeConfig.ShippingRates = stripeProcessor.ParseShippingRates(stripeShippingRates)
+	stripeConfig.ShippingCountries = stripeProcessor.ParseCountries(stripeShippingCountries)
+
+	stripeProcessor := stripeProcessor.NewProcessor(stripeConfig)
+	gateway, err := gateway.NewGateway(reg, dialOpts...)
+	if err != nil {
+		logger.Fatal("failed to create gateway", zap.Error(err))
+	}
 
 	service := NewPaymentService(stripeProcessor, gateway)
 	serviceWithTelemetry := NewtelemetryMiddleware(service)
 	serviceWithLogging := NewloggingMiddleware(serviceWithTelemetry)
 
-	amqpConsumer := NewConsumer(serviceWithLogging)
-	// listen method
-	go amqpConsumer.Listen(ch)
+	// HTTPServer
+	mux := http.NewServeMux()
+	httpServer := NewPaymentHTTPHandler(amqpBroker, serviceWithLogging)
+	httpServer.registerRouters(mux)
+	mux.HandleFunc("GET /health/breakers", resilience.HandleBreakers)
+
+	if dlqAdminToken != "" {
+		broker.NewDLQHandler(broker.NewDLQ(amqpBroker), dlqAdminToken).RegisterRoutes(mux)
+	}
+
+	consumer := NewConsumer(serviceWithLogging, broker.ConsumerConfig{
+		Queue:    broker.OrderCreatedEvent,
+		Workers:  consumerWorkers,
+		Prefetch: consumerPrefetch,
+		Timeout:  time.Duration(consumerTimeout) * time.Millisecond,
+		Retry:    retryPolicy,
+	})
+
+	app := lifecycle.New(lifecycle.Config{
+		Registry: reg,
+		Instance: discovery.NewInstance(serviceName, gRPCAddr, "grpc"),
+	})
+	app.AddCheck("rabbitmq", amqpBroker.Ping)
+	// stripe rate limits its API, so don't ask on every heartbeat
+	app.AddCheck("stripe", lifecycle.Cached(stripeProcessor.Ping, 30*time.Second))
+	app.AddGRPCServer(gRPCServer, l)
+	app.AddHTTPServer(&http.Server{Addr: httpAddr, Handler: mux})
+	app.Go("consumer", func(ctx context.Context) error { return consumer.Listen(ctx, amqpBroker) })
+	app.AddCloser("gateway", func(context.Context) error { return gateway.Close() })
+	app.AddCloser("rabbitmq", func(context.Context) error { return amqpBroker.Close() })
 
 	// store := NewStore()
 	// service := NewService(store)
@@ -118,7 +191,7 @@ func main() {
 
 	logger.Info("gRPC server has been started", zap.String("port", gRPCAddr))
 
-	if err := gRPCServer.Serve(l); err != nil {
-		logger.Fatal("failed to serve gRPC server", zap.Error(err))
+	if err := app.Run(ctx); err != nil {
+		logger.Fatal("failed to serve", zap.Error(err))
 	}
 }
@@ -0,0 +1,8 @@
+package processor
+
+import "errors"
+
+var (
+	ErrCreateCheckoutSession = errors.New("failed to create checkout session")
+	ErrCreateCoupon          = errors.New("failed to create coupon")
+)
@@ -1,6 +1,10 @@
 package inmem
 
-import pb "github.com/juxue97/common/api"
+import (
+	"context"
+
+	pb "github.com/juxue97/common/api"
+)
 
 type inmem struct{}
 
@@ -8,10 +12,6 @@ func NewInmem() *inmem {
 	return &inmem{}
 }
 
-func (i *inmem) CreatePaymentLink(o *pb.Order) (string, error) {
+func (i *inmem) CreatePaymentLink(ctx context.Context, o *pb.Order) (string, error) {
 	return "dummy-link", nil
 }
-
-func (s *inmem) CreateProduct(p *pb.Product) (string, string, error) {
-	return "dummy-product-id", "dummy-price-id", nil
-}
@@ -1,10 +1,11 @@
 package processor
 
 import (
+	"context"
+
 	pb "github.com/juxue97/common/api"
 )
 
 type PaymentProcessor interface {
-	CreatePaymentLink(*pb.Order) (string, error)
-	CreateProduct(*pb.Product) (string, string, error)
+	CreatePaymentLink(context.Context, *pb.Order) (string, error)
 }
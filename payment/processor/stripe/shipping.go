@@ -0,0 +1,97 @@
+package stripe
+
+import (
+	"sort"
+	"strings"
+
+	pb "github.com/juxue97/common/api"
+	"github.com/stripe/stripe-go/v81"
+)
+
+// maxShippingOptions is the most shipping options Checkout accepts.
+const maxShippingOptions = 5
+
+// ParseShippingRates reads a "method=shippingRateID" comma separated list,
+// e.g. "standard=shr_123,express=shr_456".
+func ParseShippingRates(s string) map[string]string {
+	rates := map[string]string{}
+	for _, entry := range strings.Split(s, ",") {
+		method, rate, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || method == "" || rate == "" {
+			continue
+		}
+		rates[strings.TrimSpace(method)] = strings.TrimSpace(rate)
+	}
+
+	return rates
+}
+
+func ParseCountries(s string) []string {
+	var countries []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.ToUpper(strings.TrimSpace(c)); c != "" {
+			countries = append(countries, c)
+		}
+	}
+
+	return countries
+}
+
+// shippingParams asks Checkout to collect a shipping address for orders that
+// need delivery and offers the chosen shipping method, or every configured one
+// when the method is unknown.
+func (s *Stripe) shippingParams(o *pb.Order, params *stripe.CheckoutSessionParams) {
+	if o.ShippingAddress == nil && o.ShippingMethod == "" {
+		return
+	}
+
+	countries := s.cfg.ShippingCountries
+	if len(countries) == 0 && o.ShippingAddress != nil && o.ShippingAddress.Country != "" {
+		countries = []string{o.ShippingAddress.Country}
+	}
+	if len(countries) > 0 {
+		params.ShippingAddressCollection = &stripe.CheckoutSessionShippingAddressCollectionParams{
+			AllowedCountries: stripe.StringSlice(countries),
+		}
+	}
+
+	if rate, ok := s.cfg.ShippingRates[o.ShippingMethod]; ok {
+		params.ShippingOptions = []*stripe.CheckoutSessionShippingOptionParams{
+			{ShippingRate: stripe.String(rate)},
+		}
+		return
+	}
+
+	methods := make([]string, 0, len(s.cfg.ShippingRates))
+	for method := range s.cfg.ShippingRates {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		if len(params.ShippingOptions) == maxShippingOptions {
+			break
+		}
+		params.ShippingOptions = append(params.ShippingOptions, &stripe.CheckoutSessionShippingOptionParams{
+			ShippingRate: stripe.String(s.cfg.ShippingRates[method]),
+		})
+	}
+}
+
+// ShippingAddress converts the address collected by Checkout.
+func ShippingAddress(d *stripe.ShippingDetails) *pb.Address {
+	if d == nil || d.Address == nil {
+		return nil
+	}
+
+	return &pb.Address{
+		Name:       d.Name,
+		Line1:      d.Address.Line1,
+		Line2:      d.Address.Line2,
+		City:       d.Address.City,
+		State:      d.Address.State,
+		PostalCode: d.Address.PostalCode,
+		Country:    d.Address.Country,
+		Phone:      d.Phone,
+	}
+}
@@ -1,26 +1,52 @@
 package stripe
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 
 	"github.com/juxue97/common"
 	pb "github.com/juxue97/common/api"
+	"github.com/juxue97/payment/processor"
 	"github.com/stripe/stripe-go/v81"
+	"github.com/stripe/stripe-go/v81/balance"
 	"github.com/stripe/stripe-go/v81/checkout/session"
-	"github.com/stripe/stripe-go/v81/price"
-	"github.com/stripe/stripe-go/v81/product"
+	"github.com/stripe/stripe-go/v81/coupon"
 )
 
 var gatewayHTTPAddr = common.GetString("HTTP_ADDR", "http://localhost:8080")
 
-type Stripe struct{}
+type Config struct {
+	common.StripeConfig
+	// AutomaticTax lets Stripe Tax calculate tax on checkout sessions.
+	AutomaticTax bool
+	// ShippingRates maps shipping methods to Stripe shipping rate IDs.
+	ShippingRates     map[string]string
+	ShippingCountries []string
+}
+
+func DefaultConfig() Config {
+	return Config{StripeConfig: common.DefaultStripeConfig()}
+}
+
+type Stripe struct {
+	cfg Config
+}
 
-func NewProcessor() *Stripe {
-	return &Stripe{}
+func NewProcessor(cfg Config) *Stripe {
+	return &Stripe{cfg: cfg}
 }
 
-func (s *Stripe) CreatePaymentLink(o *pb.Order) (string, error) {
+// Ping checks that Stripe is reachable and accepts the configured key.
+func (s *Stripe) Ping(ctx context.Context) error {
+	params := &stripe.BalanceParams{}
+	params.Context = ctx
+	_, err := balance.Get(params)
+	return err
+}
+
+func (s *Stripe) CreatePaymentLink(ctx context.Context, o *pb.Order) (string, error) {
 	log.Printf("Creating payment link for order %v", o)
 
 	items := []*stripe.CheckoutSessionLineItemParams{}
@@ -31,20 +57,54 @@ func (s *Stripe) CreatePaymentLink(o *pb.Order) (string, error) {
 		})
 	}
 
+	// tax worked out by the order service itself (local tax table)
+	if o.Tax > 0 {
+		items = append(items, &stripe.CheckoutSessionLineItemParams{
+			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+				Currency:   stripe.String(o.Currency),
+				UnitAmount: stripe.Int64(o.Tax),
+				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+					Name: stripe.String("Tax"),
+				},
+			},
+			Quantity: stripe.Int64(1),
+		})
+	}
+
 	gatewaySuccessURL := fmt.Sprintf("%s/success.html?customerID=%s&orderID=%s", gatewayHTTPAddr, o.CustomerID, o.ID)
 	gatewayCancelURL := fmt.Sprintf("%s/cancel.html", gatewayHTTPAddr)
 
-	params := &stripe.CheckoutSessionParams{
-		SuccessURL: stripe.String(gatewaySuccessURL),
-		CancelURL:  stripe.String(gatewayCancelURL),
-		LineItems:  items,
-		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
-		Metadata: map[string]string{
-			"orderID":    o.ID,
-			"customerID": o.CustomerID,
-		},
+	discounts, err := s.orderDiscounts(ctx, o)
+	if err != nil {
+		return "", err
 	}
-	result, err := session.New(params)
+
+	var result *stripe.CheckoutSession
+	err = common.CallStripe(ctx, s.cfg.StripeConfig, "checkout.session.New", processor.ErrCreateCheckoutSession, func(ctx context.Context, key string) error {
+		params := &stripe.CheckoutSessionParams{
+			SuccessURL: stripe.String(gatewaySuccessURL),
+			CancelURL:  stripe.String(gatewayCancelURL),
+			LineItems:  items,
+			Discounts:  discounts,
+			Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
+			Metadata: map[string]string{
+				"orderID":    o.ID,
+				"customerID": o.CustomerID,
+			},
+		}
+		s.shippingParams(o, params)
+		if s.cfg.AutomaticTax {
+			params.AutomaticTax = &stripe.CheckoutSessionAutomaticTaxParams{
+				Enabled: stripe.Bool(true),
+			}
+		}
+		params.Context = ctx
+		params.SetIdempotencyKey(key)
+
+		var err error
+		result, err = session.New(params)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -52,30 +112,46 @@ func (s *Stripe) CreatePaymentLink(o *pb.Order) (string, error) {
 	return result.URL, nil
 }
 
-func (s *Stripe) CreateProduct(p *pb.Product) (string, string, error) {
-	// Create a new product
-	productParams := &stripe.ProductParams{
-		Name:        stripe.String(p.Name),
-		Description: stripe.String(p.Description),
-		TaxCode:     stripe.String("txcd_00000000"), // no tax
-		Active:      stripe.Bool(true),
-	}
-	prod, err := product.New(productParams)
-	if err != nil {
-		return "", "", err
+// orderDiscounts maps the discount the order service already computed onto a
+// single-use Stripe coupon, so the checkout total always equals the order total
+// whatever kind of promotion produced it. The coupon's id is derived from the
+// order's, so a redelivered order.created or a retried checkout reuses it.
+func (s *Stripe) orderDiscounts(ctx context.Context, o *pb.Order) ([]*stripe.CheckoutSessionDiscountParams, error) {
+	if o.Discount <= 0 {
+		return nil, nil
 	}
 
-	// Create a price for the product (one-time payment)
-	priceParams := &stripe.PriceParams{
-		Product:    stripe.String(prod.ID),
-		Currency:   stripe.String(p.Currency),          // Malaysian Ringgit
-		UnitAmount: stripe.Int64(int64(p.Price * 100)), // RM 999 (Stripe uses cents)
-	}
+	couponID := "order-" + o.ID
+	var c *stripe.Coupon
+	err := common.CallStripe(ctx, s.cfg.StripeConfig, "coupon.New", processor.ErrCreateCoupon, func(ctx context.Context, key string) error {
+		params := &stripe.CouponParams{
+			ID:             stripe.String(couponID),
+			Name:           stripe.String(o.DiscountCode),
+			AmountOff:      stripe.Int64(o.Discount),
+			Currency:       stripe.String(o.Currency),
+			Duration:       stripe.String(string(stripe.CouponDurationOnce)),
+			MaxRedemptions: stripe.Int64(1),
+			Metadata: map[string]string{
+				"orderID":      o.ID,
+				"discountCode": o.DiscountCode,
+			},
+		}
+		params.Context = ctx
+		params.SetIdempotencyKey(key)
 
-	price, err := price.New(priceParams)
+		var err error
+		c, err = coupon.New(params)
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceAlreadyExists {
+			getParams := &stripe.CouponParams{}
+			getParams.Context = ctx
+			c, err = coupon.Get(couponID, getParams)
+		}
+		return err
+	})
 	if err != nil {
-		log.Fatalf("Error creating price: %v", err)
+		return nil, err
 	}
 
-	return prod.ID, price.ID, nil
+	return []*stripe.CheckoutSessionDiscountParams{{Coupon: stripe.String(c.ID)}}, nil
 }
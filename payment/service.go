@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/juxue97/common"
 	pb "github.com/juxue97/common/api"
 	"github.com/juxue97/payment/gateway"
 	"github.com/juxue97/payment/processor"
@@ -22,7 +24,7 @@ func NewPaymentService(stripeProcessor processor.PaymentProcessor, gateway gatew
 
 func (s *paymentService) CreatePayment(ctx context.Context, o *pb.Order) (string, error) {
 	// connect to payment processor, return link
-	link, err := s.stripeProcessor.CreatePaymentLink(o)
+	link, err := s.stripeProcessor.CreatePaymentLink(ctx, o)
 	if err != nil {
 		return "", err
 	}
@@ -34,3 +36,35 @@ func (s *paymentService) CreatePayment(ctx context.Context, o *pb.Order) (string
 
 	return link, nil
 }
+
+// VerifyPayment cross-checks the amount reported by the payment provider
+// against the order total captured at order creation.
+func (s *paymentService) VerifyPayment(ctx context.Context, orderID, customerID string, amount int64, currency string) (*pb.Order, error) {
+	o, err := s.gateway.GetOrder(ctx, orderID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// orders created before price snapshots were captured carry no total
+	if o.Total == 0 && o.Currency == "" {
+		return o, nil
+	}
+
+	if o.Total != amount || !common.SameCurrency(o.Currency, currency) {
+		o.Status = "payment_mismatch"
+		if err := s.gateway.UpdateOrder(ctx, o); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: order %s expects %d %s, paid %d %s",
+			common.ErrAmountMismatch, o.ID, o.Total, o.Currency, amount, currency)
+	}
+
+	return o, nil
+}
+
+// ExpirePayment cancels an order whose checkout session expired unpaid, which
+// gives back the discount code it redeemed.
+func (s *paymentService) ExpirePayment(ctx context.Context, orderID, customerID string) error {
+	return s.gateway.CancelOrder(ctx, orderID, customerID)
+}
@@ -2,18 +2,35 @@ package main
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/juxue97/common"
 	"github.com/juxue97/common/api"
-	inmemRegistry "github.com/juxue97/common/discovery/inmem"
-	"github.com/juxue97/payment/gateway"
 	"github.com/juxue97/payment/processor/inmem"
 )
 
+type inmemOrdersGateway struct{}
+
+func (g *inmemOrdersGateway) UpdateOrderAfterPaymentLink(ctx context.Context, orderID, paymentLink string) error {
+	return nil
+}
+
+func (g *inmemOrdersGateway) GetOrder(ctx context.Context, orderID, customerID string) (*api.Order, error) {
+	return &api.Order{ID: orderID, CustomerID: customerID, Currency: "myr", Total: 1999}, nil
+}
+
+func (g *inmemOrdersGateway) UpdateOrder(ctx context.Context, o *api.Order) error {
+	return nil
+}
+
+func (g *inmemOrdersGateway) CancelOrder(ctx context.Context, orderID, customerID string) error {
+	return nil
+}
+
 func TestStripeService(t *testing.T) {
 	processor := inmem.NewInmem()
-	registry := inmemRegistry.NewRegistry()
-	gateway := gateway.NewGateway(registry)
+	gateway := &inmemOrdersGateway{}
 	service := NewPaymentService(processor, gateway)
 	t.Run("should create payment link", func(t *testing.T) {
 		link, err := service.CreatePayment(context.Background(), &api.Order{})
@@ -29,4 +46,15 @@ func TestStripeService(t *testing.T) {
 			t.Errorf("CreatePaymentLink failed: Supposed to be %v, but got %v", "dummy-link", link)
 		}
 	})
+
+	t.Run("should verify paid amount against order total", func(t *testing.T) {
+		if _, err := service.VerifyPayment(context.Background(), "order-1", "customer-1", 1999, "MYR"); err != nil {
+			t.Errorf("VerifyPayment failed: %v", err)
+		}
+
+		_, err := service.VerifyPayment(context.Background(), "order-1", "customer-1", 999, "myr")
+		if !errors.Is(err, common.ErrAmountMismatch) {
+			t.Errorf("VerifyPayment failed: Supposed to be %v, but got %v", common.ErrAmountMismatch, err)
+		}
+	})
 }
@@ -24,3 +24,21 @@ func (s *telemetryMiddleware) CreatePayment(ctx context.Context, o *pb.Order) (s
 
 	return s.next.CreatePayment(ctx, o)
 }
+
+func (s *telemetryMiddleware) VerifyPayment(ctx context.Context, orderID, customerID string, amount int64, currency string) (*pb.Order, error) {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent(fmt.Sprintf(
+		"VerifyPayment: %s, amount: %d %s", orderID, amount, currency,
+	))
+
+	return s.next.VerifyPayment(ctx, orderID, customerID, amount, currency)
+}
+
+func (s *telemetryMiddleware) ExpirePayment(ctx context.Context, orderID, customerID string) error {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent(fmt.Sprintf(
+		"ExpirePayment: %s", orderID,
+	))
+
+	return s.next.ExpirePayment(ctx, orderID, customerID)
+}
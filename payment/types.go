@@ -8,4 +8,6 @@ import (
 
 type PaymentService interface {
 	CreatePayment(ctx context.Context, o *pb.Order) (string, error)
+	VerifyPayment(ctx context.Context, orderID, customerID string, amount int64, currency string) (*pb.Order, error)
+	ExpirePayment(ctx context.Context, orderID, customerID string) error
 }
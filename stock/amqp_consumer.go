@@ -2,90 +2,73 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 
 	pb "github.com/juxue97/common/api"
 
 	"github.com/juxue97/common/broker"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"go.opentelemetry.io/otel"
 )
 
 type consumer struct {
 	service *loggingMiddleware
+	config  broker.ConsumerConfig
 }
 
-func NewConsumer(service *loggingMiddleware) *consumer {
-	return &consumer{service: service}
+func NewConsumer(service *loggingMiddleware, config broker.ConsumerConfig) *consumer {
+	return &consumer{service: service, config: config}
 }
 
-func (c *consumer) Listen(ch *amqp.Channel) {
-	q, err := ch.QueueDeclare("", true, false, true, false, nil)
+// Listen consumes order.paid until ctx is done.
+// The queue is declared by broker.SubscriberTopology and shared by every
+// replica of the service.
+func (c *consumer) Listen(ctx context.Context, b broker.Broker) error {
+	runner, err := broker.NewConsumer(b, c.config, c.handle)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	err = ch.QueueBind(q.Name, "", broker.OrderPaidEvent, false, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
+	return runner.Run(ctx)
+}
 
-	messages, err := ch.Consume(q.Name, "", false, false, false, false, nil)
+func (c *consumer) handle(ctx context.Context, d broker.Delivery) error {
+	paid := broker.OrderPaid{}
+	event, err := broker.Events.Decode(d, broker.OrderPaidEvent, &paid)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("failed to decode event: %v", err)
+		return broker.Permanent(err)
+	}
+	o := &pb.Order{
+		ID:         paid.OrderID,
+		CustomerID: paid.CustomerID,
 	}
 
-	var forever chan struct{}
-
-	go func() {
-		for d := range messages {
-			o := &pb.Order{}
-			if err := json.Unmarshal(d.Body, o); err != nil {
-				d.Nack(false, false)
-				log.Printf("failed to unmarshal order: %v", err)
-				continue
-			}
-
-			ctx := broker.ExtractAMQPHeaders(context.Background(), d.Headers)
-
-			tr := otel.Tracer("amqp")
-			_, messageSpan := tr.Start(ctx, fmt.Sprintf(
-				"AMQP - consume - %s", q.Name,
-			))
-
-			log.Printf("Received a message: %s", d.Body)
+	ctx = broker.ExtractAMQPHeaders(ctx, d.Headers)
+	ctx = broker.WithCorrelationID(ctx, event.CorrelationID)
 
-			order := string(d.Body)
+	tr := otel.Tracer("amqp")
+	_, messageSpan := tr.Start(ctx, fmt.Sprintf(
+		"AMQP - consume - %s", c.config.Queue,
+	))
+	defer messageSpan.End()
 
-			// access the order service database using grpc gateway
-			// using orderID, look for the product id and quantity purchased
-			itemsWithQuantity, err := c.service.GetOrderService(ctx, o)
-			if err != nil {
-				log.Printf("failed to get order: %v", err)
-				d.Nack(false, false)
-				continue
-			}
-			for _, item := range itemsWithQuantity {
-				q := int(item.Quantity)
-				_, err := c.service.DeductStock(ctx, item.ID, q)
-				if err != nil {
-					log.Printf("failed to update stock: %v", err)
-					if err := broker.HandleRetry(ch, &d); err != nil {
-						log.Printf("failed to handle retry: %v", err)
-					}
-					continue
-				}
-			}
+	log.Printf("Received a message: %s", d.Body)
 
-			messageSpan.AddEvent("stock.updated")
-			messageSpan.End()
-			log.Printf("Stock quantity updated for order: %s", order)
+	// access the order service database using grpc gateway
+	// using orderID, look for the product id and quantity purchased
+	itemsWithQuantity, err := c.service.GetOrderService(ctx, o)
+	if err != nil {
+		log.Printf("failed to get order: %v", err)
+		return err
+	}
+	if err := c.service.DeductOrderStock(ctx, paid.OrderID, itemsWithQuantity); err != nil {
+		log.Printf("failed to update stock: %v", err)
+		return err
+	}
 
-			d.Ack(false)
-		}
-	}()
+	messageSpan.AddEvent("stock.updated")
+	log.Printf("Stock quantity updated for order: %s", paid.OrderID)
 
-	<-forever
+	return nil
 }
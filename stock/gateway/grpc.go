@@ -2,32 +2,36 @@ package gateway
 
 import (
 	"context"
-	"log"
 
 	pb "github.com/juxue97/common/api"
 	"github.com/juxue97/common/discovery"
+	"github.com/juxue97/common/resilience"
+	"google.golang.org/grpc"
 )
 
 var orderServiceName = "orders"
 
 type gateway struct {
-	registry discovery.Registry
+	conn   *grpc.ClientConn
+	client pb.OrderServiceClient
 }
 
-func NewGateway(registry discovery.Registry) *gateway {
-	return &gateway{registry: registry}
-}
-
-func (g *gateway) GetOrder(ctx context.Context, o *pb.Order) (*pb.Order, error) {
-	conn, err := discovery.ServiceConnection(context.Background(), orderServiceName, g.registry)
+func NewGateway(registry discovery.Registry, opts ...grpc.DialOption) (*gateway, error) {
+	breaker := resilience.NewBreaker(orderServiceName, resilience.BreakerConfig{})
+	conn, err := discovery.NewClient(orderServiceName, registry, append(resilience.DialOptions(breaker, resilience.OrderServiceMethods...), opts...)...)
 	if err != nil {
-		log.Fatalf("Failed to dial server: %v", err)
+		return nil, err
 	}
-	defer conn.Close()
 
-	ordersClient := pb.NewOrderServiceClient(conn)
+	return &gateway{conn: conn, client: pb.NewOrderServiceClient(conn)}, nil
+}
 
-	res, err := ordersClient.GetOrderForStockUpdate(ctx, &pb.GetOrderRequest{
+func (g *gateway) Close() error {
+	return g.conn.Close()
+}
+
+func (g *gateway) GetOrder(ctx context.Context, o *pb.Order) (*pb.Order, error) {
+	res, err := g.client.GetOrderForStockUpdate(ctx, &pb.GetOrderRequest{
 		OrderID:    o.ID,
 		CustomerID: o.CustomerID,
 	})
@@ -4,20 +4,20 @@ import (
 	"context"
 
 	pb "github.com/juxue97/common/api"
-	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/juxue97/common/broker"
 	"google.golang.org/grpc"
 )
 
 type gRPCHandler struct {
 	pb.UnimplementedStockServiceServer
-	service *loggingMiddleware
-	channel *amqp.Channel
+	service   StockService
+	publisher broker.Publisher
 }
 
-func NewGRPCHandler(gRPCServer *grpc.Server, service *loggingMiddleware, channel *amqp.Channel) {
+func NewGRPCHandler(gRPCServer *grpc.Server, service StockService, publisher broker.Publisher) {
 	handler := &gRPCHandler{
-		service: service,
-		channel: channel,
+		service:   service,
+		publisher: publisher,
 	}
 	pb.RegisterStockServiceServer(gRPCServer, handler)
 }
@@ -34,7 +34,7 @@ func (g *gRPCHandler) CheckIfItemsInStock(ctx context.Context, payload *pb.Check
 	}, nil
 }
 
-func (g *gRPCHandler) CreateItem(ctx context.Context, payload *pb.CreateItemRequest) (*pb.CreateItemResponse, error) {
+func (g *gRPCHandler) CreateStockItem(ctx context.Context, payload *pb.CreateItemRequest) (*pb.CreateItemResponse, error) {
 	oID, err := g.service.CreateItem(ctx, payload)
 	if err != nil {
 		return nil, err
@@ -65,7 +65,7 @@ func (g *gRPCHandler) GetStockItem(ctx context.Context, p *pb.GetStockItemReques
 	return item, nil
 }
 
-func (g *gRPCHandler) UpdateItem(ctx context.Context, p *pb.UpdateStockItemRequest) (*pb.StockItem, error) {
+func (g *gRPCHandler) UpdateStockItem(ctx context.Context, p *pb.UpdateStockItemRequest) (*pb.StockItem, error) {
 	item, err := g.service.UpdateItem(ctx, p.Id, p)
 	if err != nil {
 		return nil, err
@@ -58,12 +58,12 @@ func (s *loggingMiddleware) UpdateStock(ctx context.Context, id string, quantity
 	return s.next.UpdateStock(ctx, id, quantity)
 }
 
-func (s *loggingMiddleware) DeductStock(ctx context.Context, id string, quantity int) (*Item, error) {
+func (s *loggingMiddleware) DeductOrderStock(ctx context.Context, orderID string, items []*pb.Item) error {
 	start := time.Now()
 	defer func() {
-		zap.L().Info("DeductStock", zap.Duration("took", time.Since(start)))
+		zap.L().Info("DeductOrderStock", zap.Duration("took", time.Since(start)))
 	}()
-	return s.next.DeductStock(ctx, id, quantity)
+	return s.next.DeductOrderStock(ctx, orderID, items)
 }
 
 func (s *loggingMiddleware) GetOrderService(ctx context.Context, o *pb.Order) ([]*pb.Item, error) {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net"
-	"net/http"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload" // put this line for all modules
@@ -12,10 +11,12 @@ import (
 	"github.com/juxue97/common/broker"
 	mongoConn "github.com/juxue97/common/db"
 	"github.com/juxue97/common/discovery"
-	"github.com/juxue97/common/discovery/consul"
+	"github.com/juxue97/common/discovery/registry"
+	"github.com/juxue97/common/interceptor"
+	"github.com/juxue97/common/lifecycle"
+	"github.com/juxue97/common/tlsconfig"
 	"github.com/juxue97/stock/gateway"
 	stripeProcessor "github.com/juxue97/stock/processor/stripe"
-	"github.com/stripe/stripe-go/v81"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -28,18 +29,35 @@ var (
 	httpAddr = common.GetString("HTTP_ADDR", ":8083")
 
 	jaegerAddr = common.GetString("JAEGER_ADDR", "localhost:4318")
-	consulAddr = common.GetString("CONSUL_ADDR", "localhost:8500")
+
+	tlsCertFile = common.GetString("TLS_CERT_FILE", "")
+	tlsKeyFile  = common.GetString("TLS_KEY_FILE", "")
+	tlsCAFile   = common.GetString("TLS_CA_FILE", "")
+	tlsMutual   = common.GetBool("TLS_MUTUAL", false)
 
 	amqpUser = common.GetString("RABBITMQ_USER", "juxue")
 	amqpPass = common.GetString("RABBITMQ_PASS", "veryStrongPassword")
 	amqpHost = common.GetString("RABBITMQ_HOST", "localhost")
 	amqpPort = common.GetString("RABBITMQ_PORT", "5672")
 
+	retryMaxAttempts = common.GetInt("RETRY_MAX_ATTEMPTS", 3)
+	retryBaseDelay   = common.GetInt("RETRY_BASE_DELAY_MS", 1000)
+	retryMaxDelay    = common.GetInt("RETRY_MAX_DELAY_MS", 60000)
+
+	consumerWorkers  = common.GetInt("CONSUMER_WORKERS", 4)
+	consumerPrefetch = common.GetInt("CONSUMER_PREFETCH", 16)
+	consumerTimeout  = common.GetInt("CONSUMER_TIMEOUT_MS", 30000)
+
+	publishConfirmTimeout = common.GetInt("PUBLISH_CONFIRM_TIMEOUT_MS", 5000)
+
 	mongoUser = common.GetString("MONGO_DB_USER", "juxue")
 	mongoPass = common.GetString("MONGO_DB_PASS", "veryStrongPassword")
 	mongoHost = common.GetString("MONGO_DB_HOST", "localhost:27017")
 
-	stripeKey = common.GetString("STRIPE_KEY", "")
+	stripeKey          = common.GetString("STRIPE_KEY", "")
+	stripeTimeout      = common.GetInt("STRIPE_TIMEOUT_MS", 10000)
+	stripeMaxAttempts  = common.GetInt("STRIPE_MAX_ATTEMPTS", 3)
+	stripeRetryBackoff = common.GetInt("STRIPE_RETRY_BACKOFF_MS", 200)
 	// endpointStripeSecret = common.GetString("ENDPOINT_STRIPE_SECRET", "whsec_...")
 )
 
@@ -57,56 +75,71 @@ func main() {
 		logger.Fatal("failed to set global tracer", zap.Error(err))
 	}
 
-	registry, err := consul.NewRegistry(consulAddr, serviceName)
+	reg, err := registry.FromEnv(serviceName)
 	if err != nil {
 		panic(err)
 	}
 
 	ctx := context.Background()
-	instanceID := discovery.GenerateInstanceID(serviceName)
-	if err := registry.Register(ctx, instanceID, serviceName, gRPCAddr); err != nil {
-		panic(err)
-	}
 
-	go func() {
-		for {
-			if err := registry.HealthCheck(instanceID, serviceName); err != nil {
-				logger.Error("failed to health check", zap.Error(err))
-			}
-			time.Sleep(time.Second * 1)
-		}
-	}()
-	defer registry.Deregister(ctx, instanceID, serviceName)
+	amqpBroker, err := broker.Connect(ctx, amqpUser, amqpPass, amqpHost, amqpPort)
+	if err != nil {
+		logger.Fatal("failed to connect to rabbitmq", zap.Error(err))
+	}
+	amqpBroker.SetConfirmTimeout(time.Duration(publishConfirmTimeout) * time.Millisecond)
 
-	ch, close := broker.Connect(amqpUser, amqpPass, amqpHost, amqpPort)
-	defer func() {
-		close()
-		ch.Close()
-	}()
+	retryPolicy := broker.RetryPolicy{
+		MaxAttempts: retryMaxAttempts,
+		BaseDelay:   time.Duration(retryBaseDelay) * time.Millisecond,
+		MaxDelay:    time.Duration(retryMaxDelay) * time.Millisecond,
+	}
+	if err := broker.SubscriberTopology(serviceName, broker.OrderPaidEvent, retryPolicy).Apply(amqpBroker); err != nil {
+		logger.Fatal("failed to declare broker topology", zap.Error(err))
+	}
 
-	stripe.Key = stripeKey
+	common.SetupStripe(stripeKey)
 
 	mongoURI := fmt.Sprintf("mongodb://%s:%s@%s", mongoUser, mongoPass, mongoHost)
 	mongoClient, err := mongoConn.ConnectToMongoDB(mongoURI)
 	if err != nil {
 		logger.Fatal("failed to connect to mongo", zap.Error(err))
 	}
-	defer func() {
-		if err = mongoClient.Disconnect(ctx); err != nil {
-			panic(err)
+
+	serverOpts := interceptor.ServerOptions(logger)
+	var dialOpts []grpc.DialOption
+	if tlsCertFile != "" {
+		certs, err := tlsconfig.Load(tlsconfig.Config{
+			CertFile: tlsCertFile,
+			KeyFile:  tlsKeyFile,
+			CAFile:   tlsCAFile,
+			Mutual:   tlsMutual,
+		})
+		if err != nil {
+			logger.Fatal("failed to load certificates", zap.Error(err))
 		}
-	}()
+		defer certs.Close()
 
-	gRPCServer := grpc.NewServer()
+		serverOpts = append(serverOpts, grpc.Creds(certs.ServerCredentials()))
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(certs.ClientCredentials()))
+	}
+
+	gRPCServer := grpc.NewServer(serverOpts...)
 
 	l, err := net.Listen("tcp", gRPCAddr)
 	if err != nil {
 		logger.Fatal("failed to listen", zap.Error(err))
 	}
-	defer l.Close()
 
-	stripeProcessor := stripeProcessor.NewProcessor()
-	gateway := gateway.NewGateway(registry)
+	stripeConfig := common.DefaultStripeConfig()
+	stripeConfig.Timeout = time.Duration(stripeTimeout) * time.Millisecond
+	stripeConfig.Retry.MaxAttempts = stripeMaxAttempts
+	stripeConfig.Retry.BaseDelay = time.Duration(stripeRetryBackoff) * time.Millisecond
+
+	stripeProcessor := stripeProcessor.NewProcessor(stripeConfig)
+	gateway, err := gateway.NewGateway(reg, dialOpts...)
+	if err != nil {
+		logger.Fatal("failed to create gateway", zap.Error(err))
+	}
 
 	store := NewStore(mongoClient)
 	service := NewStockService(store, stripeProcessor, gateway)
@@ -121,21 +154,39 @@ func main() {
 	// v1 := http.NewServeMux()
 	// v1.Handle("/api/", http.StripPrefix("/api", mux))
 
-	go func() {
-		logger.Info("starting http server", zap.String("port", httpAddr))
-		if err := http.ListenAndServe(httpAddr, v1); err != nil {
-			logger.Fatal("failed to start http server", zap.Error(err))
-		}
-	}()
-
-	NewGRPCHandler(gRPCServer, serviceWithLogging, ch)
-
-	consumer := NewConsumer(serviceWithLogging)
-	go consumer.Listen(ch)
+	// go func() {
+	// 	logger.Info("starting http server", zap.String("port", httpAddr))
+	// 	if err := http.ListenAndServe(httpAddr, v1); err != nil {
+	// 		logger.Fatal("failed to start http server", zap.Error(err))
+	// 	}
+	// }()
+
+	// the server's interceptors already log and trace gRPC calls
+	NewGRPCHandler(gRPCServer, service, amqpBroker)
+
+	consumer := NewConsumer(serviceWithLogging, broker.ConsumerConfig{
+		Queue:    broker.ServiceQueue(serviceName, broker.OrderPaidEvent),
+		Workers:  consumerWorkers,
+		Prefetch: consumerPrefetch,
+		Timeout:  time.Duration(consumerTimeout) * time.Millisecond,
+		Retry:    retryPolicy,
+	})
+
+	app := lifecycle.New(lifecycle.Config{
+		Registry: reg,
+		Instance: discovery.NewInstance(serviceName, gRPCAddr, "grpc"),
+	})
+	app.AddCheck("rabbitmq", amqpBroker.Ping)
+	app.AddCheck("mongo", func(ctx context.Context) error { return mongoClient.Ping(ctx, nil) })
+	app.AddGRPCServer(gRPCServer, l)
+	app.Go("consumer", func(ctx context.Context) error { return consumer.Listen(ctx, amqpBroker) })
+	app.AddCloser("gateway", func(context.Context) error { return gateway.Close() })
+	app.AddCloser("rabbitmq", func(context.Context) error { return amqpBroker.Close() })
+	app.AddCloser("mongo", mongoClient.Disconnect)
 
 	logger.Info("gRPC server has been started at %s", zap.String("port", gRPCAddr))
 
-	if err := gRPCServer.Serve(l); err != nil {
+	if err := app.Run(ctx); err != nil {
 		logger.Fatal("failed to serve gRPC server", zap.Error(err))
 	}
 }
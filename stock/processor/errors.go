@@ -0,0 +1,10 @@
+package processor
+
+import "errors"
+
+var (
+	ErrCreateProduct   = errors.New("failed to create product")
+	ErrUpdateProduct   = errors.New("failed to update product")
+	ErrCreatePrice     = errors.New("failed to create price")
+	ErrDeactivatePrice = errors.New("failed to deactivate old price")
+)
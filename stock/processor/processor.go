@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"time"
 
 	pb "github.com/juxue97/common/api"
@@ -8,8 +9,8 @@ import (
 )
 
 type StockProcessor interface {
-	CreateProduct(*pb.Product) (string, string, error)
-	UpdateProduct(prodID, priceID string, i Item) (string, error)
+	CreateProduct(context.Context, *pb.Product) (string, string, error)
+	UpdateProduct(ctx context.Context, prodID, priceID string, i Item) (string, error)
 }
 
 type Item struct {
@@ -23,6 +24,7 @@ type Item struct {
 	Active      bool               `bson:"active"`
 	PriceID     string             `bson:"priceID,omitempty"`
 	Metadata    map[string]string  `bson:"metadata,omitempty"`
+	TaxCode     string             `bson:"taxCode,omitempty" json:"tax_code,omitempty"`
 	CreatedAt   time.Time          `bson:"created_at,omitempty"`
 	UpdatedAt   time.Time          `bson:"updated_at,omitempty"`
 }
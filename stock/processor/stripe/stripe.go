@@ -1,9 +1,9 @@
 package stripe
 
 import (
-	"fmt"
-	"log"
+	"context"
 
+	"github.com/juxue97/common"
 	pb "github.com/juxue97/common/api"
 	"github.com/juxue97/stock/processor"
 	"github.com/stripe/stripe-go/v81"
@@ -13,59 +13,97 @@ import (
 
 // var gatewayHTTPAddr = common.GetString("HTTP_ADDR", "http://localhost:8080")
 
-type Stripe struct{}
+type Stripe struct {
+	cfg common.StripeConfig
+}
 
-func NewProcessor() *Stripe {
-	return &Stripe{}
+func NewProcessor(cfg common.StripeConfig) *Stripe {
+	return &Stripe{cfg: cfg}
 }
 
-func (s *Stripe) CreateProduct(p *pb.Product) (string, string, error) {
-	// Create a new product
-	productParams := &stripe.ProductParams{
-		Name:        stripe.String(p.Name),
-		Description: stripe.String(p.Description),
-		TaxCode:     stripe.String("txcd_00000000"), // no tax
-		Active:      stripe.Bool(true),
-		Metadata:    p.Metadata,
+// noTaxCode is Stripe's "Nontaxable" tax code, used when an item has none
+const noTaxCode = "txcd_00000000"
+
+func taxCode(code string) string {
+	if code == "" {
+		return noTaxCode
 	}
-	prod, err := product.New(productParams)
+	return code
+}
+
+func (s *Stripe) CreateProduct(ctx context.Context, p *pb.Product) (string, string, error) {
+	// Create a new product
+	var prod *stripe.Product
+	err := common.CallStripe(ctx, s.cfg, "product.New", processor.ErrCreateProduct, func(ctx context.Context, key string) error {
+		productParams := &stripe.ProductParams{
+			Name:        stripe.String(p.Name),
+			Description: stripe.String(p.Description),
+			TaxCode:     stripe.String(taxCode(p.TaxCode)),
+			Active:      stripe.Bool(true),
+			Metadata:    p.Metadata,
+		}
+		productParams.Context = ctx
+		productParams.SetIdempotencyKey(key)
+
+		var err error
+		prod, err = product.New(productParams)
+		return err
+	})
 	if err != nil {
 		return "", "", err
 	}
 
 	// Create a price for the product (one-time payment)
-	priceParams := &stripe.PriceParams{
-		Product:    stripe.String(prod.ID),
-		Currency:   stripe.String(p.Currency),            // Malaysian Ringgit
-		UnitAmount: stripe.Int64(int64((p.Price * 100))), // RM 999 (Stripe uses cents)
-	}
-
-	price, err := price.New(priceParams)
+	var newPrice *stripe.Price
+	err = common.CallStripe(ctx, s.cfg, "price.New", processor.ErrCreatePrice, func(ctx context.Context, key string) error {
+		priceParams := &stripe.PriceParams{
+			Product:    stripe.String(prod.ID),
+			Currency:   stripe.String(p.Currency),                  // Malaysian Ringgit
+			UnitAmount: stripe.Int64(common.ToMinorUnits(p.Price)), // RM 999 (Stripe uses cents)
+		}
+		priceParams.Context = ctx
+		priceParams.SetIdempotencyKey(key)
+
+		var err error
+		newPrice, err = price.New(priceParams)
+		return err
+	})
 	if err != nil {
-		log.Fatalf("Error creating price: %v", err)
+		return "", "", err
 	}
 
-	return prod.ID, price.ID, nil
+	return prod.ID, newPrice.ID, nil
 }
 
-func (s *Stripe) UpdateProduct(prodID, priceID string, i processor.Item) (string, error) {
-	productParams := &stripe.ProductParams{
-		Active: stripe.Bool(i.Active),
-	}
-
-	if i.Name != "" {
-		productParams.Name = stripe.String(i.Name)
-	}
-
-	if i.Description != "" {
-		productParams.Description = stripe.String(i.Description)
-	}
-
-	if i.Metadata != nil {
-		productParams.Metadata = i.Metadata
-	}
-
-	newProduct, err := product.Update(prodID, productParams)
+func (s *Stripe) UpdateProduct(ctx context.Context, prodID, priceID string, i processor.Item) (string, error) {
+	var newProduct *stripe.Product
+	err := common.CallStripe(ctx, s.cfg, "product.Update", processor.ErrUpdateProduct, func(ctx context.Context, key string) error {
+		productParams := &stripe.ProductParams{
+			Active: stripe.Bool(i.Active),
+		}
+
+		if i.Name != "" {
+			productParams.Name = stripe.String(i.Name)
+		}
+
+		if i.Description != "" {
+			productParams.Description = stripe.String(i.Description)
+		}
+
+		if i.Metadata != nil {
+			productParams.Metadata = i.Metadata
+		}
+
+		if i.TaxCode != "" {
+			productParams.TaxCode = stripe.String(i.TaxCode)
+		}
+		productParams.Context = ctx
+		productParams.SetIdempotencyKey(key)
+
+		var err error
+		newProduct, err = product.Update(prodID, productParams)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -75,24 +113,38 @@ func (s *Stripe) UpdateProduct(prodID, priceID string, i processor.Item) (string
 		return "", nil
 	}
 
-	newPriceParams := &stripe.PriceParams{
-		Product:    stripe.String(newProduct.ID),
-		Currency:   stripe.String(i.Currency),
-		UnitAmount: stripe.Int64(int64(i.Price * 100)),
-	}
-
 	// Deactivate the old price
-	_, err = price.Update(priceID, &stripe.PriceParams{
-		Active: stripe.Bool(false), // Mark old price as inactive
+	err = common.CallStripe(ctx, s.cfg, "price.Update", processor.ErrDeactivatePrice, func(ctx context.Context, key string) error {
+		params := &stripe.PriceParams{
+			Active: stripe.Bool(false), // Mark old price as inactive
+		}
+		params.Context = ctx
+		params.SetIdempotencyKey(key)
+
+		_, err := price.Update(priceID, params)
+		return err
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to deactivate old price: %w", err)
+		return "", err
 	}
 
 	// Create new price
-	newPrice, err := price.New(newPriceParams)
+	var newPrice *stripe.Price
+	err = common.CallStripe(ctx, s.cfg, "price.New", processor.ErrCreatePrice, func(ctx context.Context, key string) error {
+		newPriceParams := &stripe.PriceParams{
+			Product:    stripe.String(newProduct.ID),
+			Currency:   stripe.String(i.Currency),
+			UnitAmount: stripe.Int64(common.ToMinorUnits(i.Price)),
+		}
+		newPriceParams.Context = ctx
+		newPriceParams.SetIdempotencyKey(key)
+
+		var err error
+		newPrice, err = price.New(newPriceParams)
+		return err
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to create new price: %w", err)
+		return "", err
 	}
 
 	return newPrice.ID, nil
@@ -68,11 +68,13 @@ func (s *stockService) CheckIfItemInStock(ctx context.Context, p []*pb.ItemsWith
 		// Add to response only if it exists in the request
 		if exists {
 			itemsInStockPB = append(itemsInStockPB, &pb.Item{
-				ID:       stockID,
-				Name:     stockItem.Name,
-				Quantity: requiredQty,
-				PriceID:  stockItem.PriceID,
-				// Initialize other fields if needed
+				ID:        stockID,
+				Name:      stockItem.Name,
+				Quantity:  requiredQty,
+				PriceID:   stockItem.PriceID,
+				UnitPrice: common.ToMinorUnits(stockItem.Price),
+				Currency:  stockItem.Currency,
+				TaxCode:   stockItem.TaxCode,
 			})
 		}
 	}
@@ -114,7 +116,7 @@ func (s *stockService) UpdateItem(ctx context.Context, id string, p *pb.UpdateSt
 	}
 
 	var newPriceID string
-	newPriceID, err = s.stripeProcessor.UpdateProduct(oldItem.ProductId, oldItem.PriceId, updateMap)
+	newPriceID, err = s.stripeProcessor.UpdateProduct(ctx, oldItem.ProductId, oldItem.PriceId, updateMap)
 	if err != nil {
 		return nil, err
 	}
@@ -125,8 +127,16 @@ func (s *stockService) UpdateItem(ctx context.Context, id string, p *pb.UpdateSt
 	return s.store.UpdateItem(ctx, id, updateMap)
 }
 
-func (s *stockService) DeductStock(ctx context.Context, id string, quantity int) (*Item, error) {
-	return s.store.DeductStock(ctx, id, quantity)
+// DeductOrderStock can be retried after a partial failure: the items already
+// deducted for the order are skipped by the store.
+func (s *stockService) DeductOrderStock(ctx context.Context, orderID string, items []*pb.Item) error {
+	for _, item := range items {
+		if _, err := s.store.DeductStock(ctx, orderID, item.ID, int(item.Quantity)); err != nil {
+			return fmt.Errorf("deducting %s: %w", item.ID, err)
+		}
+	}
+
+	return nil
 }
 
 func (s *stockService) UpdateStock(ctx context.Context, id string, quantity int) (*pb.StockItem, error) {
@@ -151,7 +161,7 @@ func (s *stockService) DeleteItem(ctx context.Context, id string) error {
 	param := &processor.Item{
 		Active: false,
 	}
-	_, err = s.stripeProcessor.UpdateProduct(oldItem.ProductId, oldItem.PriceId, *param)
+	_, err = s.stripeProcessor.UpdateProduct(ctx, oldItem.ProductId, oldItem.PriceId, *param)
 	if err != nil {
 		return err
 	}
@@ -171,10 +181,10 @@ func (s *stockService) CreateItem(ctx context.Context, p *pb.CreateItemRequest)
 		Description: p.Description,
 		Price:       p.Price,
 		Currency:    p.Currency,
-		// TaxCode: item.TaxCode,
+		TaxCode:     p.TaxCode,
 	}
 
-	prodID, priceID, err := s.stripeProcessor.CreateProduct(product)
+	prodID, priceID, err := s.stripeProcessor.CreateProduct(ctx, product)
 	if err != nil {
 		return primitive.NilObjectID, err
 	}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/juxue97/common/api"
+)
+
+// inmemStore deducts once per order and item, like the Mongo store, and
+// fails the first attempt at failItem.
+type inmemStore struct {
+	StockStore
+	quantities map[string]int
+	deducted   map[string]bool
+	failItem   string
+}
+
+func (s *inmemStore) DeductStock(ctx context.Context, orderID, id string, quantity int) (*Item, error) {
+	if id == s.failItem {
+		s.failItem = ""
+		return nil, errors.New("connection reset")
+	}
+
+	if key := orderID + "/" + id; !s.deducted[key] {
+		s.quantities[id] -= quantity
+		s.deducted[key] = true
+	}
+
+	return &Item{Quantity: int64(s.quantities[id])}, nil
+}
+
+func TestDeductOrderStockRetry(t *testing.T) {
+	store := &inmemStore{
+		quantities: map[string]int{"item-1": 10, "item-2": 10},
+		deducted:   map[string]bool{},
+		failItem:   "item-2",
+	}
+	service := NewStockService(store, nil, nil)
+	items := []*pb.Item{
+		{ID: "item-1", Quantity: 2},
+		{ID: "item-2", Quantity: 3},
+	}
+
+	if err := service.DeductOrderStock(context.Background(), "order-1", items); err == nil {
+		t.Fatal("expected the second item to fail")
+	}
+
+	// the redelivery deducts item-2 without deducting item-1 again
+	if err := service.DeductOrderStock(context.Background(), "order-1", items); err != nil {
+		t.Fatalf("DeductOrderStock failed on redelivery: %v", err)
+	}
+
+	want := map[string]int{"item-1": 8, "item-2": 7}
+	for id, quantity := range want {
+		if store.quantities[id] != quantity {
+			t.Errorf("expected %s to have %d left, got %d", id, quantity, store.quantities[id])
+		}
+	}
+}
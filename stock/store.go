@@ -68,11 +68,11 @@ func (s *store) GetItems(ctx context.Context) ([]*pb.StockItem, error) {
 
 	var items []*pb.StockItem
 	for cursor.Next(ctx) {
-		var item *pb.StockItem
+		var item Item
 		if err := cursor.Decode(&item); err != nil {
 			return nil, fmt.Errorf("failed to decode item: %v", err)
 		}
-		items = append(items, item)
+		items = append(items, item.ToProto())
 	}
 
 	if err := cursor.Err(); err != nil {
@@ -92,7 +92,7 @@ func (s *store) GetItem(ctx context.Context, id string) (*pb.StockItem, error) {
 
 	filter := bson.M{"_id": oID}
 
-	var item *pb.StockItem
+	var item Item
 	err = col.FindOne(ctx, filter).Decode(&item)
 	if err == mongo.ErrNoDocuments {
 		return nil, common.ErrNoDoc
@@ -100,7 +100,7 @@ func (s *store) GetItem(ctx context.Context, id string) (*pb.StockItem, error) {
 		return nil, fmt.Errorf("failed to find item: %v", err)
 	}
 
-	return item, nil
+	return item.ToProto(), nil
 }
 
 func (s *store) UpdateItem(ctx context.Context, id string, item processor.Item) (*pb.StockItem, error) {
@@ -111,7 +111,7 @@ func (s *store) UpdateItem(ctx context.Context, id string, item processor.Item)
 
 	col := s.mongoDB.Database(DbName).Collection(CollectionName)
 
-	var updatedItem *pb.StockItem
+	var updatedItem Item
 	item.UpdatedAt = time.Now()
 	update := bson.M{"$set": item}
 	filter := bson.M{"_id": oID}
@@ -125,7 +125,7 @@ func (s *store) UpdateItem(ctx context.Context, id string, item processor.Item)
 		return nil, fmt.Errorf("update failed: %v", err)
 	}
 
-	return updatedItem, nil
+	return updatedItem.ToProto(), nil
 }
 
 func (s *store) UpdateStock(ctx context.Context, id string, quantity int) (*pb.StockItem, error) {
@@ -136,7 +136,7 @@ func (s *store) UpdateStock(ctx context.Context, id string, quantity int) (*pb.S
 
 	col := s.mongoDB.Database(DbName).Collection(CollectionName)
 
-	var updatedItem *pb.StockItem
+	var updatedItem Item
 	update := bson.M{"$set": bson.M{
 		"quantity":   quantity,
 		"updated_at": time.Now(),
@@ -152,10 +152,16 @@ func (s *store) UpdateStock(ctx context.Context, id string, quantity int) (*pb.S
 		return nil, fmt.Errorf("update failed: %v", err)
 	}
 
-	return updatedItem, nil
+	return updatedItem.ToProto(), nil
 }
 
-func (s *store) DeductStock(ctx context.Context, id string, quantity int) (*Item, error) {
+// deductedOrdersKept bounds the orders remembered per item; redeliveries
+// arrive long before that many newer orders.
+const deductedOrdersKept = 1000
+
+// DeductStock records orderID on the item in the same update as the
+// deduction, so a retry finds it there and doesn't deduct again.
+func (s *store) DeductStock(ctx context.Context, orderID, id string, quantity int) (*Item, error) {
 	oID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
@@ -167,19 +173,28 @@ func (s *store) DeductStock(ctx context.Context, id string, quantity int) (*Item
 	update := bson.M{
 		"$inc": bson.M{"quantity": -quantity}, // Deduct quantity
 		"$set": bson.M{"updated_at": time.Now()},
+		"$push": bson.M{"deductedOrders": bson.M{
+			"$each":  bson.A{orderID},
+			"$slice": -deductedOrdersKept,
+		}},
 	}
 	filter := bson.M{
-		"_id":      oID,
-		"quantity": bson.M{"$gte": quantity}, // Ensure enough stock
+		"_id":            oID,
+		"quantity":       bson.M{"$gte": quantity}, // Ensure enough stock
+		"deductedOrders": bson.M{"$ne": orderID},
 	}
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
 	err = col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedItem)
-	if err != nil {
+	if err == mongo.ErrNoDocuments {
+		// already deducted by an earlier delivery of the order
+		err = col.FindOne(ctx, bson.M{"_id": oID, "deductedOrders": orderID}).Decode(&updatedItem)
 		if err == mongo.ErrNoDocuments {
 			return nil, fmt.Errorf("insufficient stock or item not found")
 		}
+	}
+	if err != nil {
 		return nil, fmt.Errorf("update failed: %v", err)
 	}
 
@@ -225,6 +240,7 @@ func (s *store) CreateItem(ctx context.Context, prodID string, priceID string, i
 		Currency:    item.Currency,
 		Quantity:    item.Quantity,
 		PriceID:     priceID,
+		TaxCode:     item.TaxCode,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -42,11 +42,11 @@ func (s *telemetryMiddleware) UpdateItem(ctx context.Context, id string, p *pb.U
 	return s.next.UpdateItem(ctx, id, p)
 }
 
-func (s *telemetryMiddleware) DeductStock(ctx context.Context, id string, quantity int) (*Item, error) {
+func (s *telemetryMiddleware) DeductOrderStock(ctx context.Context, orderID string, items []*pb.Item) error {
 	span := trace.SpanFromContext(ctx)
-	span.AddEvent(fmt.Sprintf("DeductStock: %v, quantity: %v", id, quantity))
+	span.AddEvent(fmt.Sprintf("DeductOrderStock: %v, items: %v", orderID, items))
 
-	return s.next.DeductStock(ctx, id, quantity)
+	return s.next.DeductOrderStock(ctx, orderID, items)
 }
 
 func (s *telemetryMiddleware) GetOrderService(ctx context.Context, o *pb.Order) ([]*pb.Item, error) {
@@ -7,6 +7,7 @@ import (
 	pb "github.com/juxue97/common/api"
 	"github.com/juxue97/stock/processor"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type StockService interface {
@@ -15,10 +16,10 @@ type StockService interface {
 	GetItems(context.Context) ([]*pb.StockItem, error)
 	GetItem(ctx context.Context, id string) (*pb.StockItem, error)
 	CreateItem(ctx context.Context, p *pb.CreateItemRequest) (primitive.ObjectID, error)
-	UpdateItem(ctx context.Context, id string) (*pb.StockItem, error)
+	UpdateItem(ctx context.Context, id string, p *pb.UpdateStockItemRequest) (*pb.StockItem, error)
 	UpdateStock(ctx context.Context, id string, quantity int) (*pb.StockItem, error)
 	DeleteItem(ctx context.Context, id string) error
-	DeductStock(ctx context.Context, id string, quantity int) (*Item, error)
+	DeductOrderStock(ctx context.Context, orderID string, items []*pb.Item) error
 }
 
 type StockStore interface {
@@ -29,7 +30,9 @@ type StockStore interface {
 	UpdateItem(ctx context.Context, id string, item processor.Item) (*pb.StockItem, error)
 	UpdateStock(ctx context.Context, id string, quantity int) (*pb.StockItem, error)
 	DeleteItem(ctx context.Context, id string) error
-	DeductStock(ctx context.Context, id string, quantity int) (*Item, error)
+	// DeductStock deducts quantity for orderID at most once, so a redelivered
+	// order doesn't deduct the same item twice.
+	DeductStock(ctx context.Context, orderID, id string, quantity int) (*Item, error)
 }
 
 type Item struct {
@@ -43,13 +46,35 @@ type Item struct {
 	Active      bool               `bson:"active"`
 	PriceID     string             `bson:"priceID,omitempty"`
 	Metadata    map[string]string  `bson:"metadata,omitempty"`
+	TaxCode     string             `bson:"taxCode,omitempty"`
 	CreatedAt   time.Time          `bson:"created_at,omitempty"`
 	UpdatedAt   time.Time          `bson:"updated_at,omitempty"`
 }
 
+func (i *Item) ToProto() *pb.StockItem {
+	return &pb.StockItem{
+		Id:          i.ID.Hex(),
+		ProductId:   i.ProductID,
+		Name:        i.Name,
+		Description: i.Description,
+		Price:       i.Price,
+		Currency:    i.Currency,
+		Quantity:    i.Quantity,
+		Active:      i.Active,
+		PriceId:     i.PriceID,
+		Metadata:    i.Metadata,
+		TaxCode:     i.TaxCode,
+		CreatedAt:   timestamppb.New(i.CreatedAt),
+		UpdatedAt:   timestamppb.New(i.UpdatedAt),
+	}
+}
+
 type ItemStock struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty"`
 	Name     string             `bson:"name,omitempty"`
 	Quantity int64              `bson:"quantity,omitempty"`
 	PriceID  string             `bson:"priceID,omitempty"`
+	Price    float64            `bson:"price,truncate,omitempty"`
+	Currency string             `bson:"currency,omitempty"`
+	TaxCode  string             `bson:"taxCode,omitempty"`
 }